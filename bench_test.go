@@ -0,0 +1,110 @@
+package go_npecheck
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"strings"
+	"testing"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/packages"
+)
+
+// runBenchPass 直接执行 Run, 不计入加载和类型检查的耗时, 返回提示数量
+func runBenchPass(b *testing.B, fset *token.FileSet, files []*ast.File, pkg *types.Package, info *types.Info) int {
+	count := 0
+	pass := &analysis.Pass{
+		Analyzer:          Analyzer,
+		Fset:              fset,
+		Files:             files,
+		Pkg:               pkg,
+		TypesInfo:         info,
+		TypesSizes:        types.SizesFor("gc", "amd64"),
+		Report:            func(analysis.Diagnostic) { count++ },
+		ImportObjectFact:  func(types.Object, analysis.Fact) bool { return false },
+		ExportObjectFact:  func(types.Object, analysis.Fact) {},
+		ImportPackageFact: func(*types.Package, analysis.Fact) bool { return false },
+		ExportPackageFact: func(analysis.Fact) {},
+	}
+
+	if _, err := Run(pass); err != nil {
+		b.Fatal(err)
+	}
+
+	return count
+}
+
+// generateSyntheticFunc 生成一个包含 stmtCount 组赋值、校验和引用语句的大函数, 不依赖其他包
+func generateSyntheticFunc(stmtCount int) string {
+	var sb strings.Builder
+	sb.WriteString("package synth\n\n")
+	sb.WriteString("type Data struct {\n\tA    int\n\tNext *Data\n\tList []*Data\n}\n\n")
+	sb.WriteString("func Big(d *Data, items []*Data) {\n")
+	for i := 0; i < stmtCount; i++ {
+		fmt.Fprintf(&sb, "\tx%d := d.List[%d].Next\n", i, i)
+		fmt.Fprintf(&sb, "\tif x%d != nil && x%d.Next != nil {\n\t\tprintln(x%d.Next.A, items[%d].A)\n\t}\n", i, i, i, i%8)
+		fmt.Fprintf(&sb, "\tfor _, item := range x%d.List {\n\t\tprintln(item.Next.A)\n\t}\n", i)
+	}
+	sb.WriteString("}\n")
+
+	return sb.String()
+}
+
+// BenchmarkSyntheticFunc 不同大小的单个函数, 耗时应随语句数近似线性增长
+func BenchmarkSyntheticFunc(b *testing.B) {
+	for _, stmtCount := range []int{100, 1000, 5000} {
+		b.Run(fmt.Sprintf("stmts=%d", stmtCount), func(b *testing.B) {
+			fset := token.NewFileSet()
+			file, err := parser.ParseFile(fset, "synth.go", generateSyntheticFunc(stmtCount), parser.ParseComments)
+			if err != nil {
+				b.Fatal(err)
+			}
+
+			info := &types.Info{
+				Types:      make(map[ast.Expr]types.TypeAndValue),
+				Defs:       make(map[*ast.Ident]types.Object),
+				Uses:       make(map[*ast.Ident]types.Object),
+				Instances:  make(map[*ast.Ident]types.Instance),
+				Selections: make(map[*ast.SelectorExpr]*types.Selection),
+			}
+			pkg, err := new(types.Config).Check("synth", fset, []*ast.File{file}, info)
+			if err != nil {
+				b.Fatal(err)
+			}
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				runBenchPass(b, fset, []*ast.File{file}, pkg, info)
+			}
+		})
+	}
+}
+
+// BenchmarkStdlib GOROOT 中的真实包
+func BenchmarkStdlib(b *testing.B) {
+	for _, pkgPath := range []string{"encoding/json", "go/types", "net/http"} {
+		b.Run(pkgPath, func(b *testing.B) {
+			cfg := &packages.Config{
+				Mode: packages.NeedName | packages.NeedFiles | packages.NeedSyntax | packages.NeedTypes |
+					packages.NeedTypesInfo | packages.NeedTypesSizes,
+			}
+
+			pkgs, err := packages.Load(cfg, pkgPath)
+			if err != nil {
+				b.Fatal(err)
+			}
+
+			if packages.PrintErrors(pkgs) > 0 {
+				b.Fatalf("failed to load %s", pkgPath)
+			}
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				runBenchPass(b, pkgs[0].Fset, pkgs[0].Syntax, pkgs[0].Types, pkgs[0].TypesInfo)
+			}
+		})
+	}
+}
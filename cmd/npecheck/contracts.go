@@ -0,0 +1,161 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"go/types"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	check "github.com/chenfeining/go-npecheck"
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/packages"
+)
+
+const contractsUsage = `npecheck contracts: report the nil contracts of exported functions.
+
+Usage: npecheck contracts [-format=markdown|json] [flags] [packages]
+
+Each pointer parameter is checked, dereferenced unconditionally or passed through,
+and each pointer result is never nil or may be nil. The analyzer flags, such as
+-guards and -receivers, apply as they do when checking.
+
+Flags:
+`
+
+// runContracts npecheck contracts 子命令, 返回退出码
+func runContracts(args []string) int {
+	flagSet := flag.NewFlagSet("contracts", flag.ContinueOnError)
+	format := flagSet.String("format", "markdown", "output format, markdown or json")
+	check.Analyzer.Flags.VisitAll(func(f *flag.Flag) {
+		flagSet.Var(f.Value, f.Name, f.Usage)
+	})
+	flagSet.Usage = func() {
+		fmt.Fprint(flagSet.Output(), contractsUsage)
+		flagSet.PrintDefaults()
+	}
+
+	if err := flagSet.Parse(args); err != nil {
+		return 2
+	}
+
+	if *format != "markdown" && *format != "json" {
+		fmt.Fprintf(os.Stderr, "npecheck contracts: unknown -format %q, want markdown or json\n", *format)
+		return 2
+	}
+
+	patterns := flagSet.Args()
+	if len(patterns) == 0 {
+		patterns = []string{"."}
+	}
+
+	contractList, err := loadContracts(patterns)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "npecheck contracts: %v\n", err)
+		return 1
+	}
+
+	if *format == "json" {
+		err = writeContractsJSON(os.Stdout, contractList)
+	} else {
+		err = writeContractsMarkdown(os.Stdout, contractList)
+	}
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "npecheck contracts: %v\n", err)
+		return 1
+	}
+
+	return 0
+}
+
+// loadContracts 加载并类型检查 patterns 中的包, 依次计算每个包的约束; 其他包导出的 fact 不可用
+func loadContracts(patterns []string) ([]*check.FuncContract, error) {
+	cfg := &packages.Config{
+		Mode: packages.NeedName | packages.NeedFiles | packages.NeedSyntax | packages.NeedTypes |
+			packages.NeedTypesInfo | packages.NeedTypesSizes,
+	}
+
+	pkgs, err := packages.Load(cfg, patterns...)
+	if err != nil {
+		return nil, err
+	}
+
+	if packages.PrintErrors(pkgs) > 0 {
+		return nil, fmt.Errorf("failed to load packages")
+	}
+
+	wd, _ := os.Getwd()
+	contractList := make([]*check.FuncContract, 0)
+	for _, pkg := range pkgs {
+		pass := &analysis.Pass{
+			Analyzer:   check.Analyzer,
+			Fset:       pkg.Fset,
+			Files:      pkg.Syntax,
+			Pkg:        pkg.Types,
+			TypesInfo:  pkg.TypesInfo,
+			TypesSizes: pkg.TypesSizes,
+			Report: func(diagnostic analysis.Diagnostic) { // 检测中 panic 或超时的函数
+				fmt.Fprintf(os.Stderr, "%s: %s\n", pkg.Fset.Position(diagnostic.Pos), diagnostic.Message)
+			},
+			ImportObjectFact:  func(types.Object, analysis.Fact) bool { return false },
+			ExportObjectFact:  func(types.Object, analysis.Fact) {},
+			ImportPackageFact: func(*types.Package, analysis.Fact) bool { return false },
+			ExportPackageFact: func(analysis.Fact) {},
+		}
+
+		pkgContractList, err := check.GetFuncContracts(pass)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, contract := range pkgContractList {
+			if rel, err := filepath.Rel(wd, contract.File); err == nil && !strings.HasPrefix(rel, "..") {
+				contract.File = filepath.ToSlash(rel)
+			}
+		}
+		contractList = append(contractList, pkgContractList...)
+	}
+
+	return contractList, nil
+}
+
+func writeContractsJSON(w io.Writer, contractList []*check.FuncContract) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(contractList)
+}
+
+// writeContractsMarkdown 每个包一个表格, 每个参数和返回值一行
+func writeContractsMarkdown(w io.Writer, contractList []*check.FuncContract) error {
+	var sb strings.Builder
+	sb.WriteString("# Nil contracts\n")
+
+	pkgPath := ""
+	for _, contract := range contractList {
+		if contract.Package != pkgPath {
+			pkgPath = contract.Package
+			fmt.Fprintf(&sb, "\n## %s\n\n", pkgPath)
+			sb.WriteString("| Function | Value | Type | Contract |\n")
+			sb.WriteString("| --- | --- | --- | --- |\n")
+		}
+
+		for _, param := range contract.Params {
+			fmt.Fprintf(&sb, "| `%s` | param `%s` | `%s` | %s |\n", contract.Func, param.Name, param.Type, param.Contract)
+		}
+
+		for _, result := range contract.Results {
+			name := fmt.Sprintf("result %d", result.Index)
+			if result.Name != "" {
+				name = fmt.Sprintf("result `%s`", result.Name)
+			}
+			fmt.Fprintf(&sb, "| `%s` | %s | `%s` | %s |\n", contract.Func, name, result.Type, result.Contract)
+		}
+	}
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
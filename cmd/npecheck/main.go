@@ -1,10 +1,16 @@
 package main
 
 import (
+	"os"
+
 	check "github.com/chenfeining/go-npecheck"
 	"golang.org/x/tools/go/analysis/singlechecker"
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "contracts" {
+		os.Exit(runContracts(os.Args[2:]))
+	}
+
 	singlechecker.Main(check.Analyzer)
 }
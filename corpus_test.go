@@ -0,0 +1,129 @@
+package go_npecheck
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/tools/go/analysis/analysistest"
+)
+
+var updateGolden = flag.Bool("update", false, "rewrite testdata/corpus.golden with the current finding counts")
+
+// corpusPackages 回归测试使用的标准库包
+var corpusPackages = []string{
+	"bufio",
+	"database/sql",
+	"encoding/json",
+	"fmt",
+	"go/parser",
+	"go/types",
+	"net/http",
+	"os/exec",
+	"strings",
+	"text/template",
+}
+
+const corpusTimeBudget = 3 * time.Minute
+
+// corpusT 收集 analysistest 的错误, 标准库中没有 // want 注释, 所有提示都会被当作 unexpected, 只在加载失败时使用
+type corpusT struct {
+	errors []string
+}
+
+func (t *corpusT) Errorf(format string, args ...interface{}) {
+	t.errors = append(t.errors, fmt.Sprintf(format, args...))
+}
+
+// TestCorpus 检测 GOROOT 中的标准库包, 不能 panic, 不能超时, 提示数量与 testdata/corpus.golden 一致
+func TestCorpus(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping the standard library corpus in -short mode")
+	}
+
+	var (
+		collector = &corpusT{}
+		start     = time.Now()
+		results   = analysistest.Run(collector, analysistest.TestData(), Analyzer, corpusPackages...)
+		elapsed   = time.Since(start)
+	)
+
+	if elapsed > corpusTimeBudget {
+		t.Errorf("analyzing the corpus took %v, want at most %v", elapsed, corpusTimeBudget)
+	}
+
+	if len(results) == 0 {
+		t.Fatalf("failed to load the corpus: %s", strings.Join(collector.errors, "\n"))
+	}
+
+	// 同一个包的测试变体会重复报告, 按位置去重
+	posMap := make(map[string]map[string]bool)
+	for _, result := range results {
+		if result.Err != nil {
+			t.Errorf("%s: %v", result.Pass.Pkg.Path(), result.Err)
+			continue
+		}
+
+		pkgPath := result.Pass.Pkg.Path()
+		for _, diagnostic := range result.Diagnostics {
+			pos := result.Pass.Fset.Position(diagnostic.Pos)
+			if strings.HasPrefix(diagnostic.Message, NPEInternalErrorTipInfo) {
+				t.Errorf("%s: %s", pos, diagnostic.Message)
+			}
+
+			if posMap[pkgPath] == nil {
+				posMap[pkgPath] = make(map[string]bool)
+			}
+			posMap[pkgPath][fmt.Sprintf("%s: %s", pos, diagnostic.Message)] = true
+		}
+	}
+
+	var lines []string
+	for _, pkgPath := range corpusPackages {
+		lines = append(lines, fmt.Sprintf("%s %d", pkgPath, len(posMap[pkgPath])))
+	}
+
+	var (
+		goVersion  = goMinorVersion(runtime.Version())
+		goldenFile = filepath.Join(analysistest.TestData(), "corpus.golden")
+		summary    = "# " + goVersion + "\n" + strings.Join(lines, "\n") + "\n"
+	)
+
+	if *updateGolden {
+		if err := os.WriteFile(goldenFile, []byte(summary), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	content, err := os.ReadFile(goldenFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 标准库随 Go 版本变化, 只在生成 golden 文件的版本上比较
+	golden := string(content)
+	if !strings.HasPrefix(golden, "# "+goVersion+"\n") {
+		t.Skipf("corpus.golden was generated by %s, skipping the comparison on %s",
+			strings.TrimPrefix(strings.SplitN(golden, "\n", 2)[0], "# "), goVersion)
+	}
+
+	if golden != summary {
+		t.Errorf("finding counts differ from corpus.golden, rerun with -update if intended\ngot:\n%swant:\n%s", summary, golden)
+	}
+}
+
+// goMinorVersion go1.22.3 -> go1.22, devel 版本原样返回
+func goMinorVersion(version string) string {
+	parts := strings.SplitN(version, ".", 3)
+	if len(parts) < 2 {
+		return version
+	}
+
+	return parts[0] + "." + parts[1]
+}
@@ -1,10 +1,10 @@
 module github.com/chenfeining/go-npecheck
 
-go 1.19
+go 1.25.0
 
-require golang.org/x/tools v0.9.1
+require golang.org/x/tools v0.47.0
 
 require (
-	golang.org/x/mod v0.10.0 // indirect
-	golang.org/x/sys v0.8.0 // indirect
+	golang.org/x/mod v0.37.0 // indirect
+	golang.org/x/sync v0.21.0 // indirect
 )
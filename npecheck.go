@@ -1,50 +1,273 @@
 package go_npecheck
 
 import (
+	"errors"
 	"fmt"
 	"go/ast"
 	"go/token"
 	"go/types"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
 	"sort"
+	"strconv"
 	"strings"
+	"time"
 
 	"golang.org/x/tools/go/analysis"
 )
 
 var Analyzer = &analysis.Analyzer{
-	Name: "npecheck",
-	Doc:  Doc,
-	Run:  Run,
+	Name:      "npecheck",
+	Doc:       Doc,
+	Run:       Run,
+	FactTypes: []analysis.Fact{new(NilableGlobalFact), new(GuardFact)},
 }
 
 const Doc = "check potential nil pointer reference"
 
+var (
+	errorPairedResult  bool          // u, err := f(); if err != nil { return }, 之后 u 视为非 nil
+	conservativeFields bool          // d.Reset(), reset(d) 之后 d 的字段需要重新校验
+	typeAssertion      bool          // x.(*T) 未经 comma-ok 或 type switch 校验时提示
+	nilInterfaces      bool          // 接口类型的参数、字段视为可能为 nil, 如 logger.Info()
+	trustedInterfaces  string        // nilInterfaces 模式下不校验的接口, 逗号分隔
+	structParamDepth   int           // 结构体值参数中指针字段的校验深度, 如 cfg.DB 为 1, cfg.Store.DB 为 2
+	receiverFields     bool          // 方法接收者及其指针字段视为可能为 nil, 构造函数中赋值的字段除外
+	includeTests       bool          // 检测 _test.go 文件
+	guardFuncs         string        // 自定义的校验函数, 如 validate.Present:0:bool, 逗号分隔
+	includeGenerated   bool          // 检测 // Code generated ... DO NOT EDIT. 文件
+	excludePaths       string        // 不检测的文件路径, 如 vendor, third_party, *.pb.go, 逗号分隔
+	maxFuncNodes       int           // 单个函数的 AST 节点数超过时跳过该函数, 0 表示不限制
+	funcTimeout        time.Duration // 单个函数的检测耗时超过时跳过该函数, 0 表示不限制
+	explainPos         string        // 输出该位置的访问路径、来源以及各校验记录是否生效, 如 a.go:12:5
+
+	explainOutput io.Writer = os.Stderr
+)
+
+func init() {
+	Analyzer.Flags.BoolVar(&errorPairedResult, "error-paired", true,
+		"treat the pointer result of a (*T, error) call as non-nil once the paired error is checked")
+	Analyzer.Flags.BoolVar(&conservativeFields, "conservative-fields", false,
+		"invalidate checked fields of a struct after calls that receive a pointer to it")
+	Analyzer.Flags.BoolVar(&typeAssertion, "type-assert", true,
+		"report single-value type assertions not established by a comma-ok check or a type switch")
+	Analyzer.Flags.BoolVar(&nilInterfaces, "nil-interfaces", false,
+		"treat interface-typed parameters and fields as possibly nil and report method calls on them")
+	Analyzer.Flags.StringVar(&trustedInterfaces, "trusted-interfaces", "context.Context,io.Reader,io.Writer,error",
+		"comma-separated qualified interface names that -nil-interfaces never treats as nil")
+	Analyzer.Flags.IntVar(&structParamDepth, "struct-param-depth", 2,
+		"how many levels of struct-valued parameter fields are searched for pointer fields, 0 disables it")
+	Analyzer.Flags.BoolVar(&receiverFields, "receivers", false,
+		"treat method receivers and their pointer fields as possibly nil, except fields always set by a New* constructor")
+	Analyzer.Flags.BoolVar(&includeTests, "tests", false,
+		"also check _test.go files")
+	Analyzer.Flags.StringVar(&guardFuncs, "guards", "",
+		"comma-separated guard functions as qualified.Name[:argIndex[:bool|panic]], e.g. example.com/validate.Present:0:bool")
+	Analyzer.Flags.BoolVar(&includeGenerated, "generated", false,
+		"also check files with a // Code generated ... DO NOT EDIT. header")
+	Analyzer.Flags.StringVar(&excludePaths, "exclude", "",
+		"comma-separated path globs of files not to check, matched against any part of the path, e.g. vendor,third_party,*_mock.go")
+	Analyzer.Flags.IntVar(&maxFuncNodes, "max-func-nodes", 0,
+		"skip functions with more AST nodes than this, with a note; 0 means no limit")
+	Analyzer.Flags.DurationVar(&funcTimeout, "func-timeout", 0,
+		"skip functions whose analysis takes longer than this, with a note; results then depend on machine load; 0 means no limit")
+	Analyzer.Flags.StringVar(&explainPos, "explain", "",
+		"print the access paths, origins and guards considered at file.go:LINE[:COL], and why each guard did or did not apply")
+}
+
 type CheckPointerPosition struct {
 	Line      int
 	Colum     int
+	EndLine   int // 校验生效范围的结束位置, 为 0 时一直生效到函数结束, 如 if d != nil {...} 只在 if 语句块内生效
+	EndColum  int
 	IsChecked bool
-	Type      int // DefaultPtrType, SlicePtrType, ParentPtrCurNonType
+	Type      int    // DefaultPtrType, SlicePtrType, ParentPtrCurNonType
+	Reason    string // 记录的来源, 用于 -explain, 如 parameter, if condition is true
+}
+
+// IsBefore 记录位置是否在 line:column 之前
+func (c *CheckPointerPosition) IsBefore(line, column int) bool {
+	return c.Line < line || (c.Line == line && c.Colum < column)
+}
+
+// IsInScope 判断 pos 是否在校验生效范围内
+func (c *CheckPointerPosition) IsInScope(pos token.Position) bool {
+	if c.EndLine == 0 {
+		return true
+	}
+
+	return pos.Line < c.EndLine || (pos.Line == c.EndLine && pos.Column <= c.EndColum)
 }
 
 const (
 	DefaultPtrType      int = 0 // ptr
-	SlicePtrType        int = 1 // []ptr
-	ParentPtrCurNonType int = 2 //  A.B.GNode , A is ptr，B is not ptr
+	SlicePtrType        int = 1 // []ptr, map[K]ptr, [N]ptr
+	ParentPtrCurNonType int = 2 //  A.B.GNode , A is ptr，B is not ptr; cfg.DB, cfg 为结构体值参数
+)
+
+// 在函数内可确定可能为 nil 的值的记录来源, 参数、字段等来自外部的值不包括在内
+const (
+	ReasonDeclared   = "declared without a value"
+	ReasonAssignNil  = "assigned nil"
+	ReasonCallResult = "pointer result of a call"
+	ReasonElemValue  = "map, index or type assertion value"
+	ReasonRangeValue = "range value"
+	ReasonTableValue = "package-level table element" // 包级别的查找表通常在初始化时填满, 如 Typ[Invalid], 存入接口时不报告
 )
 
 func IsPointer(typ types.Type) bool {
-	_, ok := typ.(*types.Pointer)
+	switch t := typ.(type) {
+	case *types.Pointer:
+		return true
+
+	case *types.TypeParam: // 泛型参数, 如 P interface{ *T }
+		_, ok := GetCoreType(t).(*types.Pointer)
+		return ok
+	}
+
+	return false
+}
+
+// IsFunc 判断是否为函数类型, 如 func(), type Handler func()
+func IsFunc(typ types.Type) bool {
+	if typ == nil {
+		return false
+	}
+
+	_, ok := typ.Underlying().(*types.Signature)
 	return ok
 }
 
-func IsSliceIncludePointerElem(typ types.Type) bool {
-	var sliceElem, isSlice = typ.(*types.Slice)
-	if isSlice {
-		elem := sliceElem.Elem()
-		if _, ok := elem.(*types.Pointer); ok {
-			return true
+// IsMap 判断是否为 map 类型, 如 map[K]V, type Labels map[string]string
+func IsMap(typ types.Type) bool {
+	if typ == nil {
+		return false
+	}
+
+	_, ok := typ.Underlying().(*types.Map)
+	return ok
+}
+
+// IsNilable 判断是否为引用、调用或写入前需要校验 nil 的类型, 即指针、函数和 map, 以及 nil-interfaces 模式下的接口
+func IsNilable(typ types.Type) bool {
+	return IsPointer(typ) || IsFunc(typ) || IsMap(typ) || IsUntrustedInterface(typ)
+}
+
+// IsUntrustedInterface nil-interfaces 模式下判断是否为可能为 nil 的接口, 不包括泛型参数以及 trusted-interfaces 中的接口
+func IsUntrustedInterface(typ types.Type) bool {
+	if !nilInterfaces || typ == nil || !types.IsInterface(typ) {
+		return false
+	}
+
+	if _, ok := typ.(*types.TypeParam); ok {
+		return false
+	}
+
+	typeName := types.TypeString(typ, nil)
+	for _, name := range strings.Split(trustedInterfaces, ",") {
+		if strings.TrimSpace(name) == typeName {
+			return false
+		}
+	}
+
+	return true
+}
+
+// IsFuncValue 判断表达式是否为函数类型的变量, 如 cb, opts.OnError, handlers[name], 不包括函数声明和方法
+func IsFuncValue(expr ast.Expr, typeInfo *types.Info) bool {
+	if !IsFunc(typeInfo.TypeOf(expr)) {
+		return false
+	}
+
+	switch ex := expr.(type) {
+	case *ast.Ident:
+		_, ok := typeInfo.ObjectOf(ex).(*types.Var)
+		return ok
+
+	case *ast.SelectorExpr:
+		_, ok := typeInfo.ObjectOf(ex.Sel).(*types.Var)
+		return ok
+
+	case *ast.IndexExpr:
+		_, isGenericFunc := typeInfo.TypeOf(ex.X).(*types.Signature)
+		return !isGenericFunc
+	}
+
+	return false
+}
+
+// GetCoreType 获取泛型参数的核心类型, 类型集合中所有类型的底层类型相同时返回该类型, 如 interface{ *T } 为 *T, any 为 nil
+func GetCoreType(tp *types.TypeParam) types.Type {
+	iface, ok := tp.Constraint().Underlying().(*types.Interface)
+	if !ok {
+		return nil
+	}
+
+	var coreType types.Type
+	for i := 0; i < iface.NumEmbeddeds(); i++ {
+		var terms []types.Type
+		switch embedded := iface.EmbeddedType(i).(type) {
+		case *types.Union:
+			for j := 0; j < embedded.Len(); j++ {
+				terms = append(terms, embedded.Term(j).Type())
+			}
+
+		case *types.Interface:
+			continue
+
+		default:
+			terms = append(terms, embedded)
 		}
+
+		for _, term := range terms {
+			if _, ok := term.(*types.TypeParam); ok {
+				return nil
+			}
+
+			underlying := term.Underlying()
+			if coreType != nil && !types.Identical(coreType, underlying) {
+				return nil
+			}
+			coreType = underlying
+		}
+	}
+
+	return coreType
+}
+
+// GetInstanceType 获取泛型函数实例化后的类型, 如 First[User] 为 func([]*User) *User, 非泛型时为对象类型
+func GetInstanceType(ident *ast.Ident, typesInfo *types.Info) types.Type {
+	if inst, ok := typesInfo.Instances[ident]; ok {
+		return inst.Type
+	}
+
+	if obj := typesInfo.ObjectOf(ident); obj != nil {
+		return obj.Type()
+	}
+
+	return nil
+}
+
+// IsContainerIncludePointerElem 判断是否为元素是指针或函数的容器, 如 []*T, map[K]*T, [N]*T, map[K]func()
+func IsContainerIncludePointerElem(typ types.Type) bool {
+	if typ == nil {
+		return false
+	}
+
+	switch t := typ.Underlying().(type) {
+	case *types.Slice:
+		return IsNilable(t.Elem())
+
+	case *types.Map:
+		return IsNilable(t.Elem())
+
+	case *types.Array:
+		return IsNilable(t.Elem())
 	}
+
 	return false
 }
 
@@ -53,9 +276,16 @@ func GetNodeType(ident *ast.Ident, typesInfo *types.Info) (int, bool) {
 		nodeType           = NodeTypeDefaultSinglePtr
 		isReturnSingleFunc = false
 	)
-	obj := typesInfo.ObjectOf(ident)
+	if ident == nil || typesInfo == nil {
+		return NodeTypeNonSinglePtr, isReturnSingleFunc
+	}
+
+	typ := GetInstanceType(ident, typesInfo)
+	if typ == nil { // 未解析的标识符, 如 _
+		return NodeTypeNonSinglePtr, isReturnSingleFunc
+	}
 
-	sign, ok := obj.Type().(*types.Signature)
+	sign, ok := typ.(*types.Signature)
 	if ok && sign != nil && sign.Results() != nil && sign.Results().Len() == 1 { // 函数、方法
 		isReturnSingleFunc = true
 		retType := sign.Results().At(0).Type()
@@ -63,7 +293,7 @@ func GetNodeType(ident *ast.Ident, typesInfo *types.Info) (int, bool) {
 			nodeType = NodeTypeNonSinglePtr
 		}
 	} else {
-		if !IsPointer(obj.Type()) {
+		if !IsPointer(typ) && !IsUntrustedInterface(typ) {
 			nodeType = NodeTypeNonSinglePtr
 		}
 	}
@@ -85,31 +315,59 @@ func IsPointerArray(ident *ast.Ident, info *types.Info) bool {
 		return false
 	}
 
-	elem := arr.Elem()
-	_, ok = elem.(*types.Pointer)
-	if ok {
-		return true
-	}
-
-	return false
+	return IsPointer(arr.Elem())
 }
 
-func GetIdentPosition(p *token.Position, ident *ast.Ident, fset *token.FileSet) {
-	*p = fset.Position(ident.Pos())
-}
+// GetElemValueType 获取 map 取值、下标取值、类型断言、channel 接收表达式的值类型
+func GetElemValueType(expr ast.Expr, typeInfo *types.Info) types.Type {
+	switch ex := expr.(type) {
+	case *ast.ParenExpr:
+		return GetElemValueType(ex.X, typeInfo)
 
-func WalkSelector(expr *ast.SelectorExpr, fset *token.FileSet, walkFunc func(ast.Node)) {
-	walkFunc(expr)
+	case *ast.IndexExpr:
+		containerType := typeInfo.TypeOf(ex.X)
+		if containerType == nil {
+			return nil
+		}
 
-	ident, ok := expr.X.(*ast.Ident)
-	if ok {
-		walkFunc(ident)
-		return
-	}
+		if ptr, ok := containerType.Underlying().(*types.Pointer); ok { // *[N]T
+			containerType = ptr.Elem()
+		}
+
+		switch typ := containerType.Underlying().(type) {
+		case *types.Map:
+			return typ.Elem()
+		case *types.Slice:
+			return typ.Elem()
+		case *types.Array:
+			return typ.Elem()
+		}
+
+	case *ast.TypeAssertExpr:
+		if ex.Type != nil {
+			return typeInfo.TypeOf(ex.Type)
+		}
+
+	case *ast.UnaryExpr:
+		if ex.Op != token.ARROW {
+			return nil
+		}
+
+		chanType := typeInfo.TypeOf(ex.X)
+		if chanType == nil {
+			return nil
+		}
 
-	if se, ok := expr.X.(*ast.SelectorExpr); ok {
-		WalkSelector(se, fset, walkFunc)
+		if ch, ok := chanType.Underlying().(*types.Chan); ok {
+			return ch.Elem()
+		}
 	}
+
+	return nil
+}
+
+func GetIdentPosition(p *token.Position, ident *ast.Ident, fset *token.FileSet) {
+	*p = fset.Position(ident.Pos())
 }
 
 func (f *FuncDelChecker) isRootComeFromOutside(varName string) bool {
@@ -124,800 +382,3158 @@ func (f *FuncDelChecker) isRootComeFromOutside(varName string) bool {
 		}
 	}
 
+	// 结构体值参数的指针字段, 如 cfg.DB, cfg.DB.Conn
+	for i := 2; i <= len(varNameNodes); i++ {
+		recordList := f.needCheckPointerPositionMap[strings.Join(varNameNodes[:i], ".")]
+		if len(recordList) > 0 && recordList[0].Type == ParentPtrCurNonType {
+			return true
+		}
+	}
+
 	return false
 }
 
-func (f *FuncDelChecker) isExistFuncRetPtr(nodeList []*SelectNode, isNeedRemoveLeaf bool) (bool, int) {
-	if len(nodeList) == 0 {
-		return false, -1
-	}
+// NilableGlobalFact 标记可能为 nil 的全局指针变量, 供引用该变量的其他包使用
+type NilableGlobalFact struct{}
 
-	if isNeedRemoveLeaf {
-		nodeList = nodeList[0 : len(nodeList)-1]
-	}
+func (*NilableGlobalFact) AFact() {}
 
-	for index, n := range nodeList {
-		if n.Type == NodeTypeDefaultSinglePtr && n.IsReturnSingleFunc == true {
-			return true, index
+func (*NilableGlobalFact) String() string { return "nilable" }
+
+// GetNilableGlobalMap 获取当前包中可能为 nil 的全局指针变量, 除非每次赋值都非 nil, 且只在 init() 或包级别初始化时赋值
+//
+//	var db *sql.DB
+//	func init() { db = mustOpen() } // db 非 nil
+//	func main() { db = open() }     // db 可能为 nil
+func GetNilableGlobalMap(pass *analysis.Pass) map[*types.Var]bool {
+	var (
+		scope            = pass.Pkg.Scope()
+		initializedMap   = make(map[*types.Var]bool) // 在包级别或 init() 中赋值为非 nil
+		unsafeAssignMap  = make(map[*types.Var]bool) // 赋值为 nil, 或在其他函数中赋值
+		nilableGlobalMap = make(map[*types.Var]bool)
+	)
+
+	for _, name := range scope.Names() {
+		if obj, ok := scope.Lookup(name).(*types.Var); ok && IsPointer(obj.Type()) {
+			nilableGlobalMap[obj] = true
 		}
 	}
 
-	return false, -2
-}
-
-func (f *FuncDelChecker) recordIfBinaryNilValidation(binaryExpr *ast.BinaryExpr, fset *token.FileSet, lintErrors *[]*LintError) {
-	if binaryExpr == nil {
-		return
+	if len(nilableGlobalMap) == 0 {
+		return nilableGlobalMap
 	}
-	y := binaryExpr.Y
-	if ident, ok := y.(*ast.Ident); ok {
-		if ident.Name != "nil" { // 后面可以看看这里有无其他项目种常用的alias, 比如RedisNil
+
+	recordAssign := func(lhs, rhs ast.Expr, isInit bool) {
+		ident, ok := lhs.(*ast.Ident)
+		if !ok {
 			return
 		}
 
-		if binaryExpr.Op != token.NEQ && binaryExpr.Op != token.EQL { // 只要 != nil , == nil 判断
+		obj, ok := pass.TypesInfo.ObjectOf(ident).(*types.Var)
+		if !ok || !nilableGlobalMap[obj] {
 			return
 		}
-		x := binaryExpr.X
-		if expr, ok := x.(*ast.Ident); ok {
-			var pos token.Position
-			GetIdentPosition(&pos, expr, fset)
-			if f.isRootComeFromOutside(expr.Name) /*|| (isReturnSingleFunc == true && nodeType == NodeTypeDefaultSinglePtr) */ {
-				checkedPos := &CheckPointerPosition{
-					Line:      pos.Line,
-					Colum:     pos.Column,
-					IsChecked: true,
+
+		if isInit && !IsNilIdent(rhs) {
+			initializedMap[obj] = true
+		} else {
+			unsafeAssignMap[obj] = true
+		}
+	}
+
+	for _, file := range pass.Files {
+		for _, decl := range file.Decls {
+			switch decl := decl.(type) {
+			case *ast.GenDecl:
+				for _, spec := range decl.Specs {
+					if valueSpec, ok := spec.(*ast.ValueSpec); ok && len(valueSpec.Values) > 0 {
+						for i, name := range valueSpec.Names {
+							recordAssign(name, valueSpec.Values[len(valueSpec.Values)*i/len(valueSpec.Names)], true)
+						}
+					}
+				}
+
+			case *ast.FuncDecl:
+				if decl.Body == nil {
+					continue
 				}
 
-				index := f.findFirstSuitablePosIndexFromStart(expr.Name, pos)
-				if index >= 0 {
-					if f.needCheckPointerPositionMap[expr.Name][index].IsChecked != true {
-						f.needCheckPointerPositionMap[expr.Name][index] = checkedPos
+				isInit := decl.Recv == nil && decl.Name.Name == "init"
+				for _, stmt := range decl.Body.List {
+					// 只有 init() 顶层的简单语句一定执行, 如 if os.Getenv("DSN") != "" { db = &DB{} } 中的赋值不算初始化
+					isInitialized := isInit
+					switch stmt.(type) {
+					case *ast.AssignStmt, *ast.ExprStmt, *ast.DeclStmt:
+					default:
+						isInitialized = false
+					}
 
-						if originName, ok := f.originFieldMap[expr.Name]; ok {
-							f.needCheckPointerPositionMap[originName] = f.needCheckPointerPositionMap[expr.Name]
+					recordStmtAssign := func(lhs, rhs ast.Expr) {
+						if isInit && !isInitialized && !IsNilIdent(rhs) { // init() 中的条件赋值既不算初始化, 也不算不安全的赋值
+							return
 						}
+						recordAssign(lhs, rhs, isInitialized)
 					}
+
+					ast.Inspect(stmt, func(n ast.Node) bool {
+						switch ex := n.(type) {
+						case *ast.AssignStmt:
+							for i, lhs := range ex.Lhs {
+								var rhs ast.Expr = ex.Rhs[0] // db, err = sql.Open(dsn)
+								if len(ex.Lhs) == len(ex.Rhs) {
+									rhs = ex.Rhs[i]
+								}
+								recordStmtAssign(lhs, rhs)
+							}
+
+						case *ast.UnaryExpr: // flag.StringVar(&name, ...), 通过指针赋值
+							if ex.Op == token.AND {
+								recordStmtAssign(ex.X, ex)
+							}
+						}
+						return true
+					})
 				}
 			}
 		}
 	}
 
-	binaryExpr = f.recordBinaryExpr(binaryExpr, fset, lintErrors)
+	for obj := range nilableGlobalMap {
+		if initializedMap[obj] && !unsafeAssignMap[obj] {
+			delete(nilableGlobalMap, obj)
+		}
+	}
+
+	return nilableGlobalMap
 }
 
-func (f *FuncDelChecker) recordBinaryExpr(
-	expr *ast.BinaryExpr,
-	fset *token.FileSet,
-	lintErrorList *[]*LintError) *ast.BinaryExpr {
-	left := expr.X
-	right := expr.Y
-	switch leftExpr := left.(type) {
-	case *ast.BinaryExpr:
-		x := leftExpr.X
-		switch expr := x.(type) {
-		case *ast.SelectorExpr:
-			_ = f.travelSelectorNameAndFuncWithRecord(expr, fset, lintErrorList)
-		case *ast.Ident:
-			f.recordIdentCheckedPosition(expr, fset)
-		}
-		f.recordBinaryExpr(leftExpr, fset, lintErrorList)
+// GuardFact 标记校验函数, 返回 true 或未 panic 时第 ArgIndex 个参数非 nil
+type GuardFact struct {
+	ArgIndex int
+	IsPanic  bool // 参数为 nil 时 panic, 如 must.NotNil(d)
+}
 
-	case *ast.SelectorExpr:
-		_ = f.travelSelectorNameAndFuncWithRecord(leftExpr, fset, lintErrorList)
+func (*GuardFact) AFact() {}
 
-	case *ast.CallExpr:
-		if selectExpr, ok := leftExpr.Fun.(*ast.SelectorExpr); ok {
-			_ = f.travelSelectorNameAndFuncWithRecord(selectExpr, fset, lintErrorList)
-		}
+func (g *GuardFact) String() string {
+	if g.IsPanic {
+		return fmt.Sprintf("guard(%d, panic)", g.ArgIndex)
 	}
+	return fmt.Sprintf("guard(%d, bool)", g.ArgIndex)
+}
 
-	switch rightExpr := right.(type) {
-	case *ast.BinaryExpr:
-		x := rightExpr.X
-		switch expr := x.(type) {
-		case *ast.SelectorExpr:
-			_ = f.travelSelectorNameAndFuncWithRecord(expr, fset, lintErrorList)
+// ParseGuardFuncs 解析 -guards 参数, 格式为 qualified.Name[:argIndex[:bool|panic]], 返回 FullName -> GuardFact
+//
+//	example.com/validate.Present:0:bool
+//	(*example.com/check.Checker).NotNil:1:panic
+func ParseGuardFuncs(value string) (map[string]*GuardFact, error) {
+	guardMap := make(map[string]*GuardFact)
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
 
-		case *ast.Ident:
-			f.recordIdentCheckedPosition(expr, fset)
+		var (
+			parts = strings.Split(item, ":")
+			fact  = &GuardFact{}
+		)
+
+		if len(parts) > 3 || parts[0] == "" {
+			return nil, fmt.Errorf("invalid guard function %q", item)
 		}
-		f.recordBinaryExpr(rightExpr, fset, lintErrorList)
 
-	case *ast.SelectorExpr:
-		_ = f.travelSelectorNameAndFuncWithRecord(rightExpr, fset, lintErrorList)
+		if len(parts) > 1 {
+			argIndex, err := strconv.Atoi(parts[1])
+			if err != nil || argIndex < 0 {
+				return nil, fmt.Errorf("invalid argument index in guard function %q", item)
+			}
+			fact.ArgIndex = argIndex
+		}
 
-	case *ast.CallExpr:
-		if selectExpr, ok := rightExpr.Fun.(*ast.SelectorExpr); ok {
-			_ = f.travelSelectorNameAndFuncWithRecord(selectExpr, fset, lintErrorList)
+		if len(parts) > 2 {
+			switch parts[2] {
+			case "bool":
+			case "panic":
+				fact.IsPanic = true
+			default:
+				return nil, fmt.Errorf("invalid kind in guard function %q, want bool or panic", item)
+			}
 		}
-	}
 
-	newExpr := &ast.BinaryExpr{
-		Op: expr.Op,
-		X:  left,
-		Y:  right,
+		guardMap[parts[0]] = fact
 	}
 
-	return newExpr
+	return guardMap, nil
 }
 
-func (f *FuncDelChecker) isExistRecord(name string) ([]*CheckPointerPosition, bool) {
-	recordList, ok := f.needCheckPointerPositionMap[name]
-	return recordList, ok
-}
+// GetGuardFuncMap 推断当前包中的校验函数, 函数体为对参数的 nil 比较
+//
+//	func Present(u *User) bool { return u != nil }             // bool
+//	func NotNil(p *Pool) { if p == nil { panic("nil pool") } } // panic
+func GetGuardFuncMap(pass *analysis.Pass) map[*types.Func]*GuardFact {
+	guardMap := make(map[*types.Func]*GuardFact)
+	for _, file := range pass.Files {
+		for _, decl := range file.Decls {
+			fnDecl, ok := decl.(*ast.FuncDecl)
+			if !ok || fnDecl.Body == nil || len(fnDecl.Body.List) == 0 {
+				continue
+			}
 
-func (f *FuncDelChecker) findFirstSuitablePosIndexFromStart(name string, pos token.Position) int {
-	needCheckPointerPositionList, ok := f.needCheckPointerPositionMap[name]
-	if !ok || len(needCheckPointerPositionList) == 0 {
-		return -1
-	}
+			fn, ok := pass.TypesInfo.Defs[fnDecl.Name].(*types.Func)
+			if !ok {
+				continue
+			}
 
-	if len(needCheckPointerPositionList) == 1 {
-		return 0
-	}
+			params := fn.Type().(*types.Signature).Params()
+			getParamIndex := func(expr ast.Expr) int {
+				ident, ok := expr.(*ast.Ident)
+				if !ok {
+					return -1
+				}
 
-	for i, v := range needCheckPointerPositionList {
-		if pos.Line > v.Line || (pos.Line == v.Line && pos.Column > v.Colum) {
-			nextIndex := i + 1
-			if nextIndex == len(needCheckPointerPositionList) {
-				return i
+				for i := 0; i < params.Len(); i++ {
+					typ := params.At(i).Type()
+					if params.At(i) == pass.TypesInfo.Uses[ident] && IsGuardableType(typ) {
+						return i
+					}
+				}
+				return -1
 			}
 
-			nextV := needCheckPointerPositionList[nextIndex]
-			if pos.Line < nextV.Line || (pos.Line == v.Line && pos.Column < nextV.Colum) {
-				return i
+			switch s := fnDecl.Body.List[0].(type) {
+			case *ast.ReturnStmt: // return u != nil
+				if len(fnDecl.Body.List) == 1 && len(s.Results) == 1 {
+					if argIndex := GetNilComparedParam(s.Results[0], true, getParamIndex); argIndex >= 0 {
+						guardMap[fn] = &GuardFact{ArgIndex: argIndex}
+					}
+				}
+
+			case *ast.IfStmt: // if p == nil { panic(...) }
+				if s.Init != nil || s.Else != nil || len(s.Body.List) == 0 {
+					continue
+				}
+
+				lastStmt, ok := s.Body.List[len(s.Body.List)-1].(*ast.ExprStmt)
+				if !ok {
+					continue
+				}
+
+				if call, ok := lastStmt.X.(*ast.CallExpr); !ok || !IsTerminatingCall(call, pass.TypesInfo) {
+					continue
+				}
+
+				if argIndex := GetNilComparedParam(s.Cond, false, getParamIndex); argIndex >= 0 {
+					guardMap[fn] = &GuardFact{ArgIndex: argIndex, IsPanic: true}
+				}
 			}
 		}
 	}
 
-	return -2
+	return guardMap
 }
 
-func (f *FuncDelChecker) findFirstSuitablePosIndexFromEnd(name string, pos token.Position) int {
-	needCheckPointerPositionList, ok := f.needCheckPointerPositionMap[name]
-	if !ok {
-		return -1
+// IsGuardableType 可推断为校验函数参数的类型: 指针, map, 函数, channel, 切片;
+// 接口参数除外, 装箱到接口中的 nil 指针不等于 nil, 如 func Present(v interface{}) bool { return v != nil }
+func IsGuardableType(typ types.Type) bool {
+	if IsPointer(typ) {
+		return true
 	}
 
-	index := len(needCheckPointerPositionList) - 1
-	for index >= 0 {
-		if needCheckPointerPositionList[index].Line < pos.Line ||
-			(needCheckPointerPositionList[index].Line == pos.Line &&
-				needCheckPointerPositionList[index].Colum < pos.Column) {
-			return index
-		}
-
-		index--
+	switch typ.Underlying().(type) {
+	case *types.Map, *types.Signature, *types.Chan, *types.Slice:
+		return true
 	}
 
-	return -2
+	return false
 }
 
-func (f *FuncDelChecker) recordIdentCheckedPosition(expr *ast.Ident, fset *token.FileSet) {
-	var pos token.Position
-	GetIdentPosition(&pos, expr, fset)
-	if f.isRootComeFromOutside(expr.Name) {
-		checkedPosition := &CheckPointerPosition{
-			Line:      pos.Line,
-			Colum:     pos.Column,
-			IsChecked: true,
+// GetNilComparedParam 获取条件为 true 时 isNonNil 成立的参数下标, 如 u != nil, !(u == nil), 没有时返回 -1
+func GetNilComparedParam(cond ast.Expr, isNonNil bool, getParamIndex func(ast.Expr) int) int {
+	switch ex := cond.(type) {
+	case *ast.ParenExpr:
+		return GetNilComparedParam(ex.X, isNonNil, getParamIndex)
+
+	case *ast.UnaryExpr:
+		if ex.Op == token.NOT {
+			return GetNilComparedParam(ex.X, !isNonNil, getParamIndex)
 		}
 
-		index := f.findFirstSuitablePosIndexFromStart(expr.Name, pos)
-		if index >= 0 {
-			if f.needCheckPointerPositionMap[expr.Name][index].IsChecked != true {
-				f.needCheckPointerPositionMap[expr.Name][index] = checkedPosition
-				if originName, ok := f.originFieldMap[expr.Name]; ok {
-					f.needCheckPointerPositionMap[originName] = f.needCheckPointerPositionMap[expr.Name]
-				}
+	case *ast.BinaryExpr:
+		if ex.Op == token.LAND && isNonNil || ex.Op == token.LOR && !isNonNil { // u != nil && u.Name != "", 任一侧成立即可
+			if argIndex := GetNilComparedParam(ex.X, isNonNil, getParamIndex); argIndex >= 0 {
+				return argIndex
 			}
+			return GetNilComparedParam(ex.Y, isNonNil, getParamIndex)
+		}
+
+		if ex.Op != token.EQL && ex.Op != token.NEQ || (ex.Op == token.NEQ) != isNonNil {
+			return -1
+		}
+
+		if IsNilIdent(ex.Y) {
+			return getParamIndex(ex.X)
+		}
+
+		if IsNilIdent(ex.X) {
+			return getParamIndex(ex.Y)
 		}
 	}
+
+	return -1
 }
 
-func (f *FuncDelChecker) preRecordNilPointerFromOutside(fnDel *ast.FuncDecl, lintErrors *[]*LintError) {
-	if fnDel == nil {
-		return
+// GetCalleeFunc 获取调用的函数或方法, 泛型函数返回其声明, 函数变量返回 nil
+func GetCalleeFunc(call *ast.CallExpr, typeInfo *types.Info) *types.Func {
+	var ident *ast.Ident
+	switch fn := UnwrapFuncInstance(call.Fun).(type) {
+	case *ast.Ident:
+		ident = fn
+	case *ast.SelectorExpr:
+		ident = fn.Sel
+	default:
+		return nil
 	}
 
-	var (
-		typeInfo = f.pass.TypesInfo
-		fset     = f.pass.Fset
-	)
+	if fn, ok := typeInfo.Uses[ident].(*types.Func); ok {
+		return fn.Origin()
+	}
 
-	if fnDel.Type != nil && fnDel.Type.Params != nil {
-		for _, field := range fnDel.Type.Params.List {
-			for _, name := range field.Names {
-				typ := typeInfo.Types[field.Type].Type
-				var pos token.Position
-				GetIdentPosition(&pos, name, fset)
-				if IsPointer(typ) {
-					f.needCheckPointerPositionMap[name.Name] = []*CheckPointerPosition{
-						{
-							Line:      pos.Line,
-							Colum:     pos.Column,
-							IsChecked: false,
-						},
-					}
-				} else if IsSliceIncludePointerElem(typ) {
-					f.needCheckPointerPositionMap[name.Name] = []*CheckPointerPosition{
-						{
-							Line:      pos.Line,
-							Colum:     pos.Column,
-							IsChecked: true,
-							Type:      SlicePtrType,
-						},
-					}
-				}
-			}
-		}
-	}
-
-	// 获取函数外部赋值的指针变量
-	for _, stmt := range fnDel.Body.List {
-		f.recordStmtNilValidation(stmt, fset, lintErrors, typeInfo)
-	}
+	return nil
 }
 
-func (f *FuncDelChecker) recordStmtNilValidation(stmt ast.Stmt, fset *token.FileSet, lintErrors *[]*LintError, typeInfo *types.Info) {
-	switch s := stmt.(type) {
-	case *ast.IfStmt:
-		f.recordIfStmtNilValidation(s, fset, lintErrors, typeInfo)
-
-	case *ast.AssignStmt:
-		f.recordAssignmentStmtNilValidation(s, typeInfo, fset)
-
-	case *ast.RangeStmt:
-		f.recordRangeStmtNilValidation(s, typeInfo, fset, lintErrors)
-
-	case *ast.SwitchStmt:
-		f.recordSwitchStmtNilValidation(s, typeInfo, fset, lintErrors)
+// getGuardFact 获取校验函数的 GuardFact, 依次查找 -guards 参数、当前包中推断的以及其他包导出的校验函数
+func (f *FuncDelChecker) getGuardFact(fn *types.Func) *GuardFact {
+	if fact, ok := f.configuredGuardMap[fn.FullName()]; ok {
+		return fact
 	}
-}
 
-func (f *FuncDelChecker) recordIfStmtNilValidation(s *ast.IfStmt, fset *token.FileSet, lintErrors *[]*LintError, typeInfo *types.Info) {
-	cond := s.Cond
-	switch cond := cond.(type) {
-	case *ast.BinaryExpr:
-		f.recordIfBinaryNilValidation(cond, fset, lintErrors)
+	if fact, ok := f.guardFuncMap[fn]; ok {
+		return fact
 	}
 
-	for _, stmt := range s.Body.List {
-		switch expr := stmt.(type) {
-		case *ast.IfStmt, *ast.AssignStmt, *ast.RangeStmt:
-			f.recordStmtNilValidation(expr, fset, lintErrors, typeInfo)
+	if fn.Pkg() != nil && fn.Pkg() != f.pass.Pkg {
+		fact := &GuardFact{}
+		if f.pass.ImportObjectFact(fn, fact) {
+			return fact
 		}
 	}
+
+	return nil
 }
 
-func (f *FuncDelChecker) recordSwitchStmtNilValidation(s *ast.SwitchStmt, typeInfo *types.Info, fset *token.FileSet, lintErrors *[]*LintError) {
-	if s == nil {
-		return
+// getGuardCond 将校验函数的调用转换为等价的条件, isPanic 表示校验失败时是否 panic
+//
+//	validate.Present(d) -> d != nil
+//	require.NoError(t, err) -> err == nil, isPanic
+func (f *FuncDelChecker) getGuardCond(call *ast.CallExpr) (guardCond ast.Expr, isPanic bool) {
+	typeInfo := f.pass.TypesInfo
+	if guardCond := GetTestifyGuardCond(call, typeInfo); guardCond != nil {
+		return guardCond, IsTestifyPackage(call, typeInfo, "require")
 	}
 
-	if s.Body == nil {
-		return
+	fn := GetCalleeFunc(call, typeInfo)
+	if fn == nil {
+		return nil, false
 	}
 
-	for _, c := range s.Body.List {
-		if caseClause, ok := c.(*ast.CaseClause); ok {
-			for _, b := range caseClause.Body {
-				switch expr := b.(type) {
-				case *ast.AssignStmt, *ast.IfStmt, *ast.SwitchStmt, *ast.RangeStmt:
-					f.recordStmtNilValidation(expr, fset, lintErrors, typeInfo)
-				}
-			}
-		}
+	fact := f.getGuardFact(fn)
+	if fact == nil || fact.ArgIndex >= len(call.Args) {
+		return nil, false
 	}
-}
 
-func (f *FuncDelChecker) recordRangeStmtNilValidation(s *ast.RangeStmt, typeInfo *types.Info, fset *token.FileSet, lintErrors *[]*LintError) {
-	var (
-		x               = s.X
-		rangeParentName string
-	)
+	return &ast.BinaryExpr{X: call.Args[fact.ArgIndex], Op: token.NEQ, Y: ast.NewIdent("nil")}, fact.IsPanic
+}
 
-	switch expr := x.(type) {
-	case *ast.Ident:
-		rangeParentName = expr.Name
-		if _, ok := f.needCheckPointerPositionMap[rangeParentName]; ok {
-			value := s.Value
-			switch value.(type) {
-			case *ast.Ident:
-				f.recordRangeValue(s, fset)
-			}
-		}
+// recordGlobalVars 记录引用的可能为 nil 的全局指针变量, 包括其他包中通过 NilableGlobalFact 标记的变量, 如 db, store.DB
+func (f *FuncDelChecker) recordGlobalVars(node ast.Node, pos token.Position) {
+	if node == nil {
+		return
+	}
 
-	case *ast.SelectorExpr:
-		innerX := expr.X
-		switch innerX := innerX.(type) {
+	ast.Inspect(node, func(n ast.Node) bool {
+		switch ex := n.(type) {
 		case *ast.Ident:
-			rangeParentName = innerX.Name
-			if _, ok := f.needCheckPointerPositionMap[rangeParentName]; ok {
-				if IsPointerArray(expr.Sel, f.pass.TypesInfo) {
-					lintErrorList := f.detectSelectorReferenceWithFunc(expr, fset)
-					if len(lintErrorList) > 0 {
-						*lintErrors = append(*lintErrors, lintErrorList...)
-					}
-
-					f.recordRangeValue(s, fset)
+			if obj, ok := f.pass.TypesInfo.Uses[ex].(*types.Var); ok && f.nilableGlobalMap[obj] {
+				if _, ok := f.needCheckPointerPositionMap[ex.Name]; !ok {
+					f.recordNeedCheckPosition(ex.Name, pos, false, "package-level variable that may be nil")
 				}
 			}
-		}
 
-	case *ast.CallExpr:
-		if exprFun, ok := expr.Fun.(*ast.SelectorExpr); ok {
-			lintErrorList := f.detectSelectorReferenceWithFunc(exprFun, fset)
-			if len(lintErrorList) > 0 {
-				*lintErrors = append(*lintErrors, lintErrorList...)
+		case *ast.SelectorExpr:
+			pkgIdent, ok := ex.X.(*ast.Ident)
+			if !ok {
+				return true
 			}
-		}
 
-		var sign = GetFuncSignature(expr, typeInfo)
-		if sign == nil {
-			return
-		}
+			if _, ok := f.pass.TypesInfo.Uses[pkgIdent].(*types.PkgName); !ok {
+				return true
+			}
 
-		if sign.Results() != nil && sign.Results().Len() != 1 {
-			return
-		}
+			obj, ok := f.pass.TypesInfo.Uses[ex.Sel].(*types.Var)
+			if !ok || !f.pass.ImportObjectFact(obj, &NilableGlobalFact{}) {
+				return true
+			}
 
-		retType := sign.Results().At(0).Type()
-		if IsSliceIncludePointerElem(retType) {
-			f.recordRangeValue(s, fset)
+			name := pkgIdent.Name + "." + ex.Sel.Name
+			if _, ok := f.needCheckPointerPositionMap[name]; !ok {
+				f.setRecordList(name, []*CheckPointerPosition{
+					{
+						Line:      pos.Line,
+						Colum:     pos.Column,
+						IsChecked: false,
+						Reason:    "package-level variable marked nilable by its package",
+						Type:      ParentPtrCurNonType,
+					},
+				})
+			}
 		}
-	}
 
-	body := s.Body
-	if body == nil {
+		return true
+	})
+}
+
+// detectGlobalVarInitializer 检测包级别变量初始化中的引用, 如 var name = db.Name
+func (f *FuncDelChecker) detectGlobalVarInitializer(decl *ast.GenDecl, lintErrorListPtr *[]*LintError) {
+	if decl.Tok != token.VAR {
 		return
 	}
 
-	for _, b := range body.List {
-		switch expr := b.(type) {
-		case *ast.IfStmt, *ast.AssignStmt, *ast.RangeStmt, *ast.SwitchStmt:
-			f.recordStmtNilValidation(expr, fset, lintErrors, typeInfo)
+	f.recordGlobalVars(decl, f.pass.Fset.Position(decl.Pos()))
+	for _, spec := range decl.Specs {
+		if valueSpec, ok := spec.(*ast.ValueSpec); ok {
+			for _, expr := range valueSpec.Values {
+				f.detectExprReference(expr, f.pass.Fset, lintErrorListPtr)
+			}
 		}
 	}
 }
 
-func (f *FuncDelChecker) recordRangeValue(s *ast.RangeStmt, fset *token.FileSet) {
-	value := s.Value
-	if v, ok := value.(*ast.Ident); ok {
-		var pos token.Position
-		GetIdentPosition(&pos, v, fset)
-		needCheckPos := &CheckPointerPosition{
-			Line:      pos.Line,
-			Colum:     pos.Column,
-			IsChecked: false,
-		}
-		recordList, ok := f.isExistRecord(v.Name)
-		if ok {
-			f.needCheckPointerPositionMap[v.Name] = append(recordList, needCheckPos)
-		} else {
-			f.needCheckPointerPositionMap[v.Name] = []*CheckPointerPosition{needCheckPos}
-		}
+// recordReceiverFields receivers 模式下记录方法接收者, 构造函数中赋值的字段视为已校验
+//
+//	func NewService(r *Repo) *Service { return &Service{repo: r} }
+//	func (s *Service) Do() { s.repo.Get() } // s 需要校验, s.repo 视为已校验
+func (f *FuncDelChecker) recordReceiverFields(recv *ast.FieldList) {
+	if len(recv.List) != 1 || len(recv.List[0].Names) != 1 || recv.List[0].Names[0].Name == "_" {
+		return
 	}
-}
 
-func (f *FuncDelChecker) recordAssignmentStmtNilValidation(s *ast.AssignStmt, typeInfo *types.Info, fset *token.FileSet) {
-	var respVarNameList = make([]string, 0)
-	for _, l := range s.Lhs {
-		if i, ok := l.(*ast.Ident); ok {
-			respVarNameList = append(respVarNameList, i.Name)
-		}
+	var (
+		name       = recv.List[0].Names[0]
+		typ        = f.pass.TypesInfo.TypeOf(recv.List[0].Type)
+		pos        = f.pass.Fset.Position(name.Pos())
+		checkType  = DefaultPtrType
+		structType = typ
+	)
+
+	if IsPointer(typ) {
+		f.recordNeedCheckPosition(name.Name, pos, false, "method receiver")
+		structType = typ.(*types.Pointer).Elem()
+	} else if st, ok := typ.Underlying().(*types.Struct); ok {
+		f.recordStructParamFields(name.Name, st, structParamDepth, pos)
+		checkType = ParentPtrCurNonType
 	}
 
-	for _, expr := range s.Rhs {
-		switch EX := expr.(type) {
-		case *ast.SelectorExpr:
-			obj := typeInfo.ObjectOf(EX.Sel)
-			if IsPointer(obj.Type()) {
-				fieldNameList := TravelSelectorName(EX, fset)
-				if len(fieldNameList) == 0 {
-					continue
-				}
+	named, ok := structType.(*types.Named)
+	if !ok {
+		return
+	}
 
-				if _, ok := f.needCheckPointerPositionMap[fieldNameList[0]]; ok {
-					key := strings.Join(fieldNameList, ".")
-					if len(respVarNameList) == 1 {
-						f.originFieldMap[respVarNameList[0]] = key
-						var pos token.Position
-						GetIdentPosition(&pos, EX.Sel, fset)
-						needCheckedPos := &CheckPointerPosition{
-							Line:      pos.Line,
-							Colum:     pos.Column,
-							IsChecked: false,
-						}
+	for fieldName := range f.constructorFieldMap[named.Origin().Obj()] {
+		f.setRecordList(name.Name+"."+fieldName, []*CheckPointerPosition{
+			{
+				Line:      pos.Line,
+				Colum:     pos.Column,
+				IsChecked: true,
+				Reason:    "field set by every New* constructor",
+				Type:      checkType,
+			},
+		})
+	}
+}
 
-						recordList, ok := f.isExistRecord(respVarNameList[0])
-						if ok {
-							f.needCheckPointerPositionMap[respVarNameList[0]] = append(recordList, needCheckedPos)
-						} else {
-							f.needCheckPointerPositionMap[respVarNameList[0]] = []*CheckPointerPosition{needCheckedPos}
-						}
-						f.needCheckPointerPositionMap[key] = f.needCheckPointerPositionMap[respVarNameList[0]]
-					}
-				}
+// GetConstructorFieldMap 获取每个结构体在所有构造函数 New* 中都赋值为非 nil 的字段,
+// 如 return &Service{repo: r}, s := &Service{}; s.cache = newCache()
+func GetConstructorFieldMap(pass *analysis.Pass) map[*types.TypeName]map[string]bool {
+	constructorFieldMap := make(map[*types.TypeName]map[string]bool)
+	for _, file := range pass.Files {
+		for _, decl := range file.Decls {
+			fnDecl, ok := decl.(*ast.FuncDecl)
+			if !ok || fnDecl.Recv != nil || fnDecl.Body == nil || !IsFuncPtrRespNeedSkip(fnDecl.Name.Name) {
+				continue
 			}
 
-		case *ast.CallExpr:
-			sign := GetFuncSignature(EX, typeInfo)
-			if sign == nil {
+			fn, ok := pass.TypesInfo.Defs[fnDecl.Name].(*types.Func)
+			if !ok {
 				continue
 			}
 
-			respVarNameListLength := len(respVarNameList)
-			if respVarNameListLength > 0 && sign != nil && sign.Results() != nil && respVarNameListLength == sign.Results().Len() {
-				for index, varName := range respVarNameList {
-					retType := sign.Results().At(index).Type()
-					pos := fset.Position(EX.Rparen)
-					var (
-						needCheckedPos *CheckPointerPosition
-						isNeedRecord   bool
-					)
-					if IsSliceIncludePointerElem(retType) {
-						needCheckedPos = &CheckPointerPosition{
-							Line:      pos.Line,
-							Colum:     pos.Column,
-							IsChecked: true,
-						}
-						isNeedRecord = true
-					}
+			results := fn.Type().(*types.Signature).Results()
+			if results.Len() == 0 {
+				continue
+			}
 
-					if IsPointer(retType) {
-						needCheckedPos = &CheckPointerPosition{
-							Line:      pos.Line,
-							Colum:     pos.Column,
-							IsChecked: false,
-						}
-						isNeedRecord = true
-					}
+			typeName := GetStructTypeName(results.At(0).Type())
+			if typeName == nil {
+				continue
+			}
 
-					if isNeedRecord {
-						recordList, ok := f.isExistRecord(varName)
-						if ok {
-							f.needCheckPointerPositionMap[varName] = append(recordList, needCheckedPos)
-						} else {
-							f.needCheckPointerPositionMap[varName] = []*CheckPointerPosition{needCheckedPos}
-						}
+			fieldMap := GetAssignedFields(fnDecl.Body, typeName, pass.TypesInfo)
+			if prevFieldMap, ok := constructorFieldMap[typeName]; ok { // 多个构造函数时取交集
+				for fieldName := range prevFieldMap {
+					if !fieldMap[fieldName] {
+						delete(prevFieldMap, fieldName)
 					}
 				}
+				continue
 			}
+			constructorFieldMap[typeName] = fieldMap
 		}
 	}
-}
-
-type LintError struct {
-	Message string
-	File    string
-	Line    int
-	Colum   int
-}
-
-const NPEMessageTipInfo = "potential nil pointer reference"
 
-// 实现 Error 方法
-func (err *LintError) Error() string {
-	return fmt.Sprintf("%s: %s:%d:%d", err.Message, err.File, err.Line, err.Colum)
+	return constructorFieldMap
 }
 
-func RemoveVarLeafNode(varName string, originFieldMap map[string]string) string {
-	if varName == "" {
-		return ""
+// GetStructTypeName 获取 T 或 *T 的结构体类型名
+func GetStructTypeName(typ types.Type) *types.TypeName {
+	if ptr, ok := typ.(*types.Pointer); ok {
+		typ = ptr.Elem()
 	}
 
-	parts := strings.Split(varName, ".")
-	var substr string
-	if len(parts) > 1 {
-		substr = strings.Join(parts[:len(parts)-1], ".")
+	named, ok := typ.(*types.Named)
+	if !ok {
+		return nil
 	}
 
-	if originName, ok := originFieldMap[substr]; ok {
-		substr = originName
+	if _, ok := named.Underlying().(*types.Struct); !ok {
+		return nil
 	}
 
-	return substr
+	return named.Origin().Obj()
 }
 
-type SelectNode struct {
-	Name               string
-	Type               int // NodeTypeDefaultSinglePtr, NodeTypeNonSinglePtr
-	IsReturnSingleFunc bool
-	CurIdent           *ast.Ident
-}
+// GetAssignedFields 获取函数体中通过复合字面量或字段赋值设置为非 nil 的字段, 只统计函数体顶层语句,
+// 如 if withCache { s.cache = c } 中的赋值不一定执行
+func GetAssignedFields(body *ast.BlockStmt, typeName *types.TypeName, typeInfo *types.Info) map[string]bool {
+	fieldMap := make(map[string]bool)
+	for _, stmt := range body.List {
+		switch stmt.(type) {
+		case *ast.AssignStmt, *ast.ReturnStmt, *ast.DeclStmt, *ast.ExprStmt:
+		default:
+			continue
+		}
 
-const (
-	NodeTypeDefaultSinglePtr int = 0
-	NodeTypeNonSinglePtr     int = 1
-)
+		ast.Inspect(stmt, func(n ast.Node) bool {
+			switch ex := n.(type) {
+			case *ast.FuncLit:
+				return false
 
-func buildFieldNameFromNodes(nodes []*SelectNode) string {
-	fieldNameList := make([]string, 0)
-	for _, n := range nodes {
-		fieldNameList = append(fieldNameList, n.Name)
+			case *ast.CompositeLit: // &Service{repo: r}
+				if GetStructTypeName(typeInfo.TypeOf(ex)) != typeName {
+					return true
+				}
+
+				st := typeName.Type().Underlying().(*types.Struct)
+				for i, elt := range ex.Elts {
+					if kv, ok := elt.(*ast.KeyValueExpr); ok {
+						if key, ok := kv.Key.(*ast.Ident); ok && !IsNilIdent(kv.Value) {
+							fieldMap[key.Name] = true
+						}
+					} else if i < st.NumFields() && !IsNilIdent(elt) {
+						fieldMap[st.Field(i).Name()] = true
+					}
+				}
+
+			case *ast.AssignStmt: // s.repo = r
+				for i, lhs := range ex.Lhs {
+					sel, ok := lhs.(*ast.SelectorExpr)
+					if !ok || len(ex.Lhs) != len(ex.Rhs) || IsNilIdent(ex.Rhs[i]) {
+						continue
+					}
+
+					if GetStructTypeName(typeInfo.TypeOf(sel.X)) == typeName {
+						fieldMap[sel.Sel.Name] = true
+					}
+				}
+			}
+
+			return true
+		})
 	}
 
-	return strings.Join(fieldNameList, ".")
+	return fieldMap
 }
 
-func walkSelectorWithFunc(expr *ast.SelectorExpr, fset *token.FileSet, walkFunc func(ast.Node)) {
-	walkFunc(expr)
-
-	switch ex := expr.X.(type) {
-	case *ast.Ident:
-		walkFunc(ex)
+// recordStructParamFields 记录结构体值参数中可能为 nil 的字段, 如 func Handle(cfg Config) 的 cfg.DB, depth 为嵌套结构体值字段的层数
+func (f *FuncDelChecker) recordStructParamFields(prefix string, st *types.Struct, depth int, pos token.Position) {
+	if depth <= 0 {
 		return
+	}
 
-	case *ast.SelectorExpr:
-		walkSelectorWithFunc(ex, fset, walkFunc)
+	for i := 0; i < st.NumFields(); i++ {
+		field := st.Field(i)
+		if field.Name() == "_" {
+			continue
+		}
 
-	case *ast.CallExpr:
-		if se, ok := ex.Fun.(*ast.SelectorExpr); ok {
-			walkSelectorWithFunc(se, fset, walkFunc)
+		name := prefix + "." + field.Name()
+		if IsNilable(field.Type()) {
+			f.setRecordList(name, []*CheckPointerPosition{
+				{
+					Line:      pos.Line,
+					Colum:     pos.Column,
+					IsChecked: false,
+					Reason:    "pointer field of a struct parameter",
+					Type:      ParentPtrCurNonType,
+				},
+			})
+			continue
 		}
 
-		if se, ok := ex.Fun.(*ast.Ident); ok {
-			walkFunc(se)
-			return
+		if fieldStruct, ok := field.Type().Underlying().(*types.Struct); ok {
+			if field.Embedded() { // 嵌入字段, 如 cfg.DB 为 cfg.Base.DB
+				f.recordStructParamFields(prefix, fieldStruct, depth, pos)
+			}
+			f.recordStructParamFields(name, fieldStruct, depth-1, pos)
 		}
 	}
 }
 
-func (f *FuncDelChecker) travelSelectorNameAndFuncWithRecord(
-	expr *ast.SelectorExpr,
-	fset *token.FileSet,
-	lintErrorList *[]*LintError,
-) []*SelectNode {
-	var nodeList = make([]*SelectNode, 0)
-	walkSelectorWithFunc(expr, fset, func(node ast.Node) {
-		switch exprInner := node.(type) {
-		case *ast.SelectorExpr:
-			if exprInner != nil && exprInner.Sel != nil {
-				nodeType, isReturnSingleFunc := GetNodeType(exprInner.Sel, f.pass.TypesInfo) // 可以再记录一个是否是函数
-				nodeList = append(nodeList, &SelectNode{
-					Name:               exprInner.Sel.Name,
-					CurIdent:           exprInner.Sel,
-					Type:               nodeType,
-					IsReturnSingleFunc: isReturnSingleFunc,
+func (f *FuncDelChecker) isExistFuncRetPtr(nodeList []*SelectNode, isNeedRemoveLeaf bool) (bool, int) {
+	if len(nodeList) == 0 {
+		return false, -1
+	}
+
+	if isNeedRemoveLeaf {
+		nodeList = nodeList[0 : len(nodeList)-1]
+	}
+
+	for index, n := range nodeList {
+		if n.Type == NodeTypeDefaultSinglePtr && n.IsReturnSingleFunc == true {
+			return true, index
+		}
+	}
+
+	return false, -2
+}
+
+func (f *FuncDelChecker) findFirstSuitablePosIndexFromEnd(name string, pos token.Position) int {
+	needCheckPointerPositionList, ok := f.needCheckPointerPositionMap[name]
+	if !ok {
+		return -1
+	}
+
+	// 记录按位置有序, 二分查找 pos 之前的最后一条记录, 再向前找到作用域包含 pos 的记录
+	index := sort.Search(len(needCheckPointerPositionList), func(i int) bool {
+		return !needCheckPointerPositionList[i].IsBefore(pos.Line, pos.Column)
+	}) - 1
+	for index >= 0 {
+		if needCheckPointerPositionList[index].IsInScope(pos) {
+			return index
+		}
+
+		index--
+	}
+
+	return -2
+}
+
+// insertCheckPointerPosition 按位置顺序插入校验记录, 同时记录到 name 的别名上, 如 a := d; if a != nil {...} 同样校验了 d
+func (f *FuncDelChecker) insertCheckPointerPosition(name string, checkPos *CheckPointerPosition) {
+	f.insertOneCheckPointerPosition(name, checkPos)
+	for _, aliasName := range f.getAliasNames(name) {
+		f.insertOneCheckPointerPosition(aliasName, checkPos)
+	}
+}
+
+// setRecordList 设置变量的校验记录, 新的变量同时加入 recordNameIndexMap
+func (f *FuncDelChecker) setRecordList(name string, recordList []*CheckPointerPosition) {
+	if _, ok := f.needCheckPointerPositionMap[name]; !ok {
+		AddNameIndex(f.recordNameIndexMap, name)
+	}
+	f.needCheckPointerPositionMap[name] = recordList
+}
+
+// AddNameIndex 按根节点和下标变量索引变量名, 如 d.A 索引到 d, items.[i] 索引到 items 和 [i]
+func AddNameIndex(indexMap map[string][]string, name string) {
+	indexMap[GetRootName(name)] = append(indexMap[GetRootName(name)], name)
+	for _, indexName := range GetIndexNames(name) {
+		indexMap[indexName] = append(indexMap[indexName], name)
+	}
+}
+
+// GetIndexedNames 获取 nameMap 中与 name 根节点相同, 或下标中引用了 name 的变量, 避免遍历整个 nameMap
+func GetIndexedNames[V any](indexMap map[string][]string, nameMap map[string]V, name string) []string {
+	var names []string
+	if strings.ContainsAny(name, "[]") { // 下标中嵌套下标, 如 items.[0] 重新赋值, 无法通过索引查找
+		for key := range nameMap {
+			names = append(names, key)
+		}
+		return names
+	}
+
+	candidateNames := append(append([]string{}, indexMap[GetRootName(name)]...), indexMap[GetIndexNodeName(&ast.Ident{Name: name})]...)
+	for _, key := range uniqueNames(candidateNames) {
+		if _, ok := nameMap[key]; ok {
+			names = append(names, key)
+		}
+	}
+
+	return names
+}
+
+// GetRootName 获取根节点, 如 d.A.B 为 d
+func GetRootName(name string) string {
+	return strings.SplitN(name, ".", 2)[0]
+}
+
+// GetIndexNames 获取变量名中最内层的下标, 如 items.[i] 为 [i], m.[keys_[0]] 为 [0]
+func GetIndexNames(name string) []string {
+	var (
+		indexNames []string
+		start      = -1
+	)
+
+	for i, c := range name {
+		switch c {
+		case '[':
+			start = i
+		case ']':
+			if start >= 0 {
+				indexNames = append(indexNames, name[start:i+1])
+			}
+			start = -1
+		}
+	}
+
+	return indexNames
+}
+
+func (f *FuncDelChecker) insertOneCheckPointerPosition(name string, checkPos *CheckPointerPosition) {
+	recordList := f.needCheckPointerPositionMap[name]
+	index := sort.Search(len(recordList), func(i int) bool { // 相同位置的记录插入在已有记录之后
+		return !recordList[i].IsBefore(checkPos.Line, checkPos.Colum+1)
+	})
+
+	recordList = append(recordList, nil)
+	copy(recordList[index+1:], recordList[index:])
+	recordList[index] = checkPos
+	f.setRecordList(name, recordList)
+	f.changedNameList = append(f.changedNameList, name)
+}
+
+// recordScopedCheckedNames 记录在 [start, end] 范围内已校验的变量, end 无效时一直生效到函数结束
+func (f *FuncDelChecker) recordScopedCheckedNames(names []string, start, end token.Pos, reason string) {
+	var (
+		startPos = f.pass.Fset.Position(start)
+		endPos   token.Position
+	)
+
+	if end.IsValid() {
+		endPos = f.pass.Fset.Position(end)
+	}
+
+	for _, name := range names {
+		f.insertCheckPointerPosition(name, &CheckPointerPosition{
+			Line:      startPos.Line,
+			Colum:     startPos.Column,
+			EndLine:   endPos.Line,
+			EndColum:  endPos.Column,
+			IsChecked: true,
+			Reason:    reason,
+		})
+	}
+}
+
+// isCheckedAt 判断变量在 pos 处是否已校验
+func (f *FuncDelChecker) isCheckedAt(name string, pos token.Pos) bool {
+	index := f.findFirstSuitablePosIndexFromEnd(name, f.pass.Fset.Position(pos))
+	return index >= 0 && f.needCheckPointerPositionMap[name][index].IsChecked
+}
+
+func (f *FuncDelChecker) preRecordNilPointerFromOutside(fnDel *ast.FuncDecl, lintErrors *[]*LintError) {
+	if fnDel == nil || fnDel.Body == nil {
+		return
+	}
+
+	var (
+		typeInfo = f.pass.TypesInfo
+		fset     = f.pass.Fset
+	)
+
+	f.recordGlobalVars(fnDel.Body, fset.Position(fnDel.Pos()))
+	if receiverFields && fnDel.Recv != nil {
+		f.recordReceiverFields(fnDel.Recv)
+	}
+
+	if fnDel.Type != nil && fnDel.Type.Params != nil {
+		for _, field := range fnDel.Type.Params.List {
+			for _, name := range field.Names {
+				typ := typeInfo.Types[field.Type].Type
+				if IsTestingParam(typ) { // func TestX(t *testing.T)
+					continue
+				}
+
+				var pos token.Position
+				GetIdentPosition(&pos, name, fset)
+				if IsNilable(typ) { // 指针, 可选的回调函数 func f(cb func()), 可能未初始化的 map, 以及注入的接口
+					f.setRecordList(name.Name, []*CheckPointerPosition{
+						{
+							Line:      pos.Line,
+							Colum:     pos.Column,
+							IsChecked: false,
+							Reason:    "parameter",
+						},
+					})
+				} else if IsContainerIncludePointerElem(typ) {
+					f.setRecordList(name.Name, []*CheckPointerPosition{
+						{
+							Line:      pos.Line,
+							Colum:     pos.Column,
+							IsChecked: true,
+							Reason:    "container parameter",
+							Type:      SlicePtrType,
+						},
+					})
+				} else if st, ok := typ.Underlying().(*types.Struct); ok {
+					f.recordStructParamFields(name.Name, st, structParamDepth, pos)
+				}
+			}
+		}
+	}
+
+	// 获取函数外部赋值的指针变量
+	f.recordBlockNilValidation(fnDel.Body.List, fnDel.Body.Rbrace, fset, lintErrors, typeInfo)
+}
+
+// recordBlockNilValidation 记录代码块内的语句, blockEnd 为代码块结束位置, 如 if d == nil { return } 之后的校验只在当前代码块内生效
+func (f *FuncDelChecker) recordBlockNilValidation(stmtList []ast.Stmt, blockEnd token.Pos, fset *token.FileSet, lintErrors *[]*LintError, typeInfo *types.Info) {
+	prevBlockEnd := f.blockEnd
+	f.blockEnd = blockEnd
+	for _, stmt := range stmtList {
+		f.recordStmtNilValidation(stmt, fset, lintErrors, typeInfo)
+	}
+	f.blockEnd = prevBlockEnd
+}
+
+func (f *FuncDelChecker) recordStmtNilValidation(stmt ast.Stmt, fset *token.FileSet, lintErrors *[]*LintError, typeInfo *types.Info) {
+	f.checkDeadline()
+	switch s := stmt.(type) {
+	case *ast.IfStmt:
+		f.recordIfStmtNilValidation(s, fset, lintErrors, typeInfo)
+
+	case *ast.AssignStmt:
+		f.recordAssignmentStmtNilValidation(s, typeInfo, fset)
+
+	case *ast.RangeStmt:
+		f.recordRangeStmtNilValidation(s, typeInfo, fset, lintErrors)
+
+	case *ast.SwitchStmt:
+		f.recordSwitchStmtNilValidation(s, typeInfo, fset, lintErrors)
+
+	case *ast.ExprStmt:
+		if call, ok := s.X.(*ast.CallExpr); ok {
+			f.recordCallKill(call, typeInfo, fset.Position(s.End()))
+			f.recordPanicGuard(call, fset)
+			f.recordFuncLitNilValidation(call, fset, lintErrors, typeInfo)
+		}
+
+	case *ast.IncDecStmt: // i++ 之后 items[i] 需要重新校验
+		if name := GetAccessPathName(s.X); name != "" {
+			f.recordFieldKill(name, fset.Position(s.End()))
+		}
+
+	case *ast.DeferStmt:
+		f.recordFuncLitNilValidation(s.Call, fset, lintErrors, typeInfo)
+
+	case *ast.GoStmt:
+		f.recordFuncLitNilValidation(s.Call, fset, lintErrors, typeInfo)
+
+	case *ast.DeclStmt:
+		f.recordDeclStmtNilValidation(s, typeInfo, fset)
+
+	case *ast.TypeSwitchStmt:
+		f.recordTypeSwitchStmtNilValidation(s, typeInfo, fset, lintErrors)
+	}
+}
+
+// recordFuncLitNilValidation 记录立即调用的闭包内的校验, 与 detectCallExprBlock 对应, 只在闭包内生效
+//
+//	defer func() { if d != nil { d.A } }()
+//	go func() { if d == nil { return }; d.A }()
+func (f *FuncDelChecker) recordFuncLitNilValidation(call *ast.CallExpr, fset *token.FileSet, lintErrors *[]*LintError, typeInfo *types.Info) {
+	if call == nil {
+		return
+	}
+
+	if fun, ok := call.Fun.(*ast.FuncLit); ok && fun.Body != nil {
+		f.recordBlockNilValidation(fun.Body.List, fun.Body.Rbrace, fset, lintErrors, typeInfo)
+	}
+}
+
+// recordTypeSwitchStmtNilValidation 记录 type switch 各分支内已确定的动态类型
+//
+//	switch x.(type) {
+//	case *T:
+//		x.(*T) // x.(*T) 已校验
+//	}
+func (f *FuncDelChecker) recordTypeSwitchStmtNilValidation(s *ast.TypeSwitchStmt, typeInfo *types.Info, fset *token.FileSet, lintErrors *[]*LintError) {
+	if s == nil || s.Body == nil {
+		return
+	}
+
+	if s.Init != nil {
+		f.recordStmtNilValidation(s.Init, fset, lintErrors, typeInfo)
+	}
+
+	var assertExpr *ast.TypeAssertExpr
+	switch assign := s.Assign.(type) {
+	case *ast.AssignStmt: // switch v := x.(type)
+		if len(assign.Rhs) == 1 {
+			assertExpr, _ = assign.Rhs[0].(*ast.TypeAssertExpr)
+		}
+
+	case *ast.ExprStmt: // switch x.(type)
+		assertExpr, _ = assign.X.(*ast.TypeAssertExpr)
+	}
+
+	var parentName string
+	if assertExpr != nil {
+		parentName = GetAccessPathName(assertExpr.X)
+	}
+
+	for _, stmt := range s.Body.List {
+		caseClause, ok := stmt.(*ast.CaseClause)
+		if !ok {
+			continue
+		}
+
+		if parentName != "" && len(caseClause.List) == 1 && !IsNilIdent(caseClause.List[0]) {
+			names := []string{parentName + "." + GetTypeAssertNodeName(caseClause.List[0])}
+			f.recordScopedCheckedNames(names, caseClause.Colon, caseClause.End(), "type switch case")
+		}
+
+		f.recordBlockNilValidation(caseClause.Body, caseClause.End(), fset, lintErrors, typeInfo)
+	}
+}
+
+// recordDeclStmtNilValidation 记录未初始化的指针、函数和 map 变量, 如 var e *MyErr, var m map[string]int
+func (f *FuncDelChecker) recordDeclStmtNilValidation(s *ast.DeclStmt, typeInfo *types.Info, fset *token.FileSet) {
+	genDecl, ok := s.Decl.(*ast.GenDecl)
+	if !ok || genDecl.Tok != token.VAR {
+		return
+	}
+
+	for _, spec := range genDecl.Specs {
+		valueSpec, ok := spec.(*ast.ValueSpec)
+		if !ok || len(valueSpec.Values) > 0 {
+			continue
+		}
+
+		for _, name := range valueSpec.Names {
+			if name.Name == "_" || !IsNilable(typeInfo.TypeOf(name)) {
+				continue
+			}
+
+			f.removeAlias(name.Name)
+			f.recordNeedCheckPosition(name.Name, fset.Position(valueSpec.End()), false, ReasonDeclared)
+		}
+	}
+}
+
+func (f *FuncDelChecker) recordIfStmtNilValidation(s *ast.IfStmt, fset *token.FileSet, lintErrors *[]*LintError, typeInfo *types.Info) {
+	_ = f.recordIfStmtBranches(s, fset, lintErrors, typeInfo)
+}
+
+// recordIfStmtBranches 分别记录 if、else 语句块内以及 if 语句之后已校验的变量, 返回 if 语句之后已校验的变量
+//
+//	if d := get(); d != nil { d.A } // if 语句块内 d 已校验
+//	if d == nil {...} else { d.A }  // else 语句块内 d 已校验
+//	if d == nil { return }          // if 语句之后 d 已校验
+func (f *FuncDelChecker) recordIfStmtBranches(s *ast.IfStmt, fset *token.FileSet, lintErrors *[]*LintError, typeInfo *types.Info) []string {
+	if s.Init != nil {
+		f.recordStmtNilValidation(s.Init, fset, lintErrors, typeInfo)
+	}
+
+	f.recordConditionReference(s.Cond, fset, lintErrors)
+	var (
+		bodyNames      = f.getNilCheckedNames(s.Cond, true)
+		elseNames      = f.getNilCheckedNames(s.Cond, false)
+		candidateNames = append(append([]string{}, bodyNames...), elseNames...)
+		changedIndex   = len(f.changedNameList)
+	)
+
+	f.recordScopedCheckedNames(bodyNames, s.Body.Lbrace, s.Body.Rbrace, "if condition is true")
+	f.recordBlockNilValidation(s.Body.List, s.Body.Rbrace, fset, lintErrors, typeInfo)
+
+	switch elseStmt := s.Else.(type) {
+	case *ast.BlockStmt:
+		f.recordScopedCheckedNames(elseNames, elseStmt.Lbrace, elseStmt.Rbrace, "if condition is false")
+		f.recordBlockNilValidation(elseStmt.List, elseStmt.Rbrace, fset, lintErrors, typeInfo)
+
+	case *ast.IfStmt: // else if
+		f.recordScopedCheckedNames(elseNames, elseStmt.Pos(), elseStmt.End(), "if condition is false")
+		prevBlockEnd := f.blockEnd
+		f.blockEnd = elseStmt.End()
+		candidateNames = append(candidateNames, f.recordIfStmtBranches(elseStmt, fset, lintErrors, typeInfo)...)
+		f.blockEnd = prevBlockEnd
+	}
+
+	// 所有未终止的分支结束时都已校验的变量, 在 if 语句之后才视为已校验
+	var afterNames []string
+	candidateNames = append(candidateNames, f.changedNameList[changedIndex:]...)
+	for _, name := range uniqueNames(candidateNames) {
+		if !IsTerminatingBlock(s.Body, f.pass.TypesInfo) && !f.isCheckedAt(name, s.Body.Rbrace) {
+			continue
+		}
+
+		switch elseStmt := s.Else.(type) {
+		case nil:
+			if !containsName(elseNames, name) && !f.isCheckedAt(name, s.Body.Lbrace) {
+				continue
+			}
+
+		case *ast.BlockStmt:
+			if !IsTerminatingBlock(elseStmt, f.pass.TypesInfo) && !f.isCheckedAt(name, elseStmt.Rbrace) {
+				continue
+			}
+
+		case *ast.IfStmt:
+			if !IsTerminatingStmt(elseStmt, f.pass.TypesInfo) && !f.isCheckedAt(name, elseStmt.End()) {
+				continue
+			}
+		}
+
+		afterNames = append(afterNames, name)
+	}
+
+	f.recordScopedCheckedNames(afterNames, s.End()-1, f.blockEnd, "checked or terminated in every branch of the if statement")
+	return afterNames
+}
+
+func (f *FuncDelChecker) recordConditionReference(cond ast.Expr, fset *token.FileSet, lintErrors *[]*LintError) {
+	f.recordErrCheckedPosition(cond, fset)
+	f.recordShortCircuitGuards(cond)
+	f.detectExprReference(cond, fset, lintErrors)
+}
+
+// recordPanicGuard 记录校验失败时 panic 的校验函数调用之后已校验的变量
+//
+//	require.NotNil(t, d)     // 之后 d 已校验
+//	require.NoError(t, err) // 之后 u 已校验, u, err := repo.Find(id)
+//	must.NotNil(d)          // 之后 d 已校验
+func (f *FuncDelChecker) recordPanicGuard(call *ast.CallExpr, fset *token.FileSet) {
+	guardCond, isPanic := f.getGuardCond(call)
+	if guardCond == nil || !isPanic {
+		return
+	}
+
+	f.recordErrCheckedPosition(call, fset)
+	f.recordScopedCheckedNames(f.getNilCheckedNames(guardCond, true), call.End(), f.blockEnd, "guard function call")
+}
+
+// recordShortCircuitGuards 记录 &&、|| 右侧表达式中由左侧表达式保证非 nil 的变量
+//
+//	d != nil && d.A != nil // d.A 处 d 已校验
+//	d == nil || d.A == nil // d.A 处 d 已校验
+func (f *FuncDelChecker) recordShortCircuitGuards(expr ast.Expr) {
+	switch ex := expr.(type) {
+	case *ast.BinaryExpr:
+		f.recordShortCircuitGuards(ex.X)
+		switch ex.Op {
+		case token.LAND:
+			f.recordScopedCheckedNames(f.getNilCheckedNames(ex.X, true), ex.OpPos, ex.Y.End(), "left operand of &&")
+		case token.LOR:
+			f.recordScopedCheckedNames(f.getNilCheckedNames(ex.X, false), ex.OpPos, ex.Y.End(), "left operand of ||")
+		}
+		f.recordShortCircuitGuards(ex.Y)
+
+	case *ast.ParenExpr:
+		f.recordShortCircuitGuards(ex.X)
+
+	case *ast.UnaryExpr:
+		f.recordShortCircuitGuards(ex.X)
+	}
+}
+
+// recordErrCheckedPosition 记录 (*T, error) 返回值中 error 变量第一次被校验的位置
+func (f *FuncDelChecker) recordErrCheckedPosition(cond ast.Expr, fset *token.FileSet) {
+	ast.Inspect(cond, func(node ast.Node) bool {
+		if ident, ok := node.(*ast.Ident); ok {
+			if valueName, ok := f.errVarMap[ident.Name]; ok {
+				if _, ok := f.errCheckedPositionMap[valueName]; !ok {
+					f.errCheckedPositionMap[valueName] = fset.Position(cond.Pos())
+				}
+			}
+		}
+		return true
+	})
+}
+
+// getNilCheckedNames 获取条件 cond 结果为 isTrue 时可确定非 nil 的变量
+//
+//	d != nil && d.A != nil 为 true 时 d, d.A 非 nil
+//	d == nil || d.A == nil 为 false 时 d, d.A 非 nil
+//	!(d == nil), nil != d 为 true 时 d 非 nil
+//	v, ok := m[k]; ok 为 true 时 v 非 nil
+//	u, err := repo.Find(id); err != nil 为 false 时 u 非 nil
+func (f *FuncDelChecker) getNilCheckedNames(cond ast.Expr, isTrue bool) []string {
+	switch cond := cond.(type) {
+	case *ast.ParenExpr:
+		return f.getNilCheckedNames(cond.X, isTrue)
+
+	case *ast.CallExpr: // if !assert.NotNil(t, d) { return }, if !validate.Present(d) { return }
+		if guardCond, isPanic := f.getGuardCond(cond); guardCond != nil && !isPanic {
+			return f.getNilCheckedNames(guardCond, isTrue)
+		}
+
+	case *ast.Ident: // if ok {...}
+		if valueName, ok := f.okVarMap[cond.Name]; ok && isTrue {
+			return []string{valueName}
+		}
+
+	case *ast.UnaryExpr: // if !ok {...}, if !(d == nil) {...}
+		if cond.Op == token.NOT {
+			return f.getNilCheckedNames(cond.X, !isTrue)
+		}
+
+	case *ast.BinaryExpr:
+		switch cond.Op {
+		case token.LAND:
+			if isTrue {
+				return uniqueNames(append(f.getNilCheckedNames(cond.X, true), f.getNilCheckedNames(cond.Y, true)...))
+			}
+			return intersectNames(f.getNilCheckedNames(cond.X, false), f.getNilCheckedNames(cond.Y, false))
+
+		case token.LOR:
+			if isTrue {
+				return intersectNames(f.getNilCheckedNames(cond.X, true), f.getNilCheckedNames(cond.Y, true))
+			}
+			return uniqueNames(append(f.getNilCheckedNames(cond.X, false), f.getNilCheckedNames(cond.Y, false)...))
+
+		case token.NEQ, token.EQL: // 只要 != nil , == nil 判断
+			x := cond.X
+			if IsNilIdent(x) { // nil != d
+				x = cond.Y
+			} else if !IsNilIdent(cond.Y) {
+				return nil
+			}
+
+			if errIdent, ok := x.(*ast.Ident); ok && errorPairedResult {
+				if valueName, ok := f.errVarMap[errIdent.Name]; ok { // err == nil 为 true 时 u 非 nil
+					if (cond.Op == token.EQL) == isTrue {
+						return []string{valueName}
+					}
+					return nil
+				}
+			}
+
+			if (cond.Op == token.NEQ) != isTrue {
+				return nil
+			}
+
+			if name := f.getCheckedName(x); name != "" {
+				return []string{name}
+			}
+		}
+	}
+
+	return nil
+}
+
+func IsNilIdent(expr ast.Expr) bool {
+	ident, ok := expr.(*ast.Ident)
+	return ok && ident.Name == "nil"
+}
+
+// getCheckedName 获取与 nil 比较的变量名, 如 d, d.A, d.GetChildNodePtr, 其父节点未校验时返回空
+func (f *FuncDelChecker) getCheckedName(expr ast.Expr) string {
+	var selectorExpr *ast.SelectorExpr
+	switch ex := expr.(type) {
+	case *ast.ParenExpr:
+		return f.getCheckedName(ex.X)
+
+	case *ast.Ident:
+		if f.isRootComeFromOutside(ex.Name) {
+			return ex.Name
+		}
+		return ""
+
+	case *ast.SelectorExpr:
+		selectorExpr = ex
+
+	case *ast.CallExpr:
+		selectorExpr, _ = ex.Fun.(*ast.SelectorExpr)
+
+	case *ast.IndexExpr: // if items[0] != nil, if d.Items[0] != nil
+		name := GetAccessPathName(ex)
+		if !f.isRootComeFromOutside(name) {
+			return ""
+		}
+
+		var lintErrors []*LintError
+		f.isSilent = true
+		f.detectExprReference(ex.X, f.pass.Fset, &lintErrors)
+		f.isSilent = false
+		if len(lintErrors) > 0 {
+			return ""
+		}
+
+		return name
+	}
+
+	if selectorExpr == nil {
+		return ""
+	}
+
+	name := GetAccessPathName(selectorExpr)
+	if !f.isRootComeFromOutside(name) {
+		return ""
+	}
+
+	// 父节点未校验时, 子节点的校验不生效, 如 if d.A != nil { d.A.B }, d 未校验
+	f.isSilent = true
+	lintErrors := f.detectSelectorReferenceWithFunc(selectorExpr, f.pass.Fset)
+	f.isSilent = false
+	if len(lintErrors) > 0 {
+		return ""
+	}
+
+	return name
+}
+
+// detectExprReference 检测表达式中的指针引用
+func (f *FuncDelChecker) detectExprReference(expr ast.Expr, fset *token.FileSet, lintErrorListPtr *[]*LintError) {
+	switch ex := expr.(type) {
+	case *ast.SelectorExpr:
+		lintErrors := f.detectSelectorReferenceWithFunc(ex, fset)
+		if len(lintErrors) > 0 {
+			*lintErrorListPtr = append(*lintErrorListPtr, lintErrors...)
+		}
+
+	case *ast.CallExpr:
+		f.detectCallExprBlock(ex, fset, lintErrorListPtr)
+
+	case *ast.ParenExpr:
+		f.detectExprReference(ex.X, fset, lintErrorListPtr)
+
+	case *ast.IndexExpr:
+		f.detectExprReference(ex.X, fset, lintErrorListPtr)
+		f.detectExprReference(ex.Index, fset, lintErrorListPtr)
+
+	case *ast.UnaryExpr:
+		f.detectExprReference(ex.X, fset, lintErrorListPtr)
+
+	case *ast.BinaryExpr:
+		f.detectExprReference(ex.X, fset, lintErrorListPtr)
+		f.detectExprReference(ex.Y, fset, lintErrorListPtr)
+	}
+}
+
+// recordSwitchStmtNilValidation 记录 switch 语句各分支以及 switch 语句之后已校验的变量
+//
+//	switch {
+//	case d == nil:
+//		return
+//	default:
+//		d.A // d 已校验
+//	}
+func (f *FuncDelChecker) recordSwitchStmtNilValidation(s *ast.SwitchStmt, typeInfo *types.Info, fset *token.FileSet, lintErrors *[]*LintError) {
+	if s == nil {
+		return
+	}
+
+	if s.Body == nil {
+		return
+	}
+
+	if s.Init != nil {
+		f.recordStmtNilValidation(s.Init, fset, lintErrors, typeInfo)
+	}
+
+	if s.Tag != nil {
+		f.recordConditionReference(s.Tag, fset, lintErrors)
+	}
+
+	var (
+		clauseList    = make([]*ast.CaseClause, 0)
+		entryNameList = make([][]string, 0)
+		falseNames    []string // 之前所有 case 条件都不成立时可确定非 nil 的变量
+		defaultIndex  = -1
+		changedIndex  = len(f.changedNameList)
+	)
+
+	for _, c := range s.Body.List {
+		caseClause, ok := c.(*ast.CaseClause)
+		if !ok {
+			continue
+		}
+
+		var entryNames []string
+		if caseClause.List == nil {
+			defaultIndex = len(clauseList)
+		} else {
+			for i, expr := range caseClause.List {
+				f.recordConditionReference(expr, fset, lintErrors)
+				names := f.getNilCheckedNames(getSwitchCaseCond(s.Tag, expr), true)
+				if i == 0 {
+					entryNames = names
+				} else {
+					entryNames = intersectNames(entryNames, names)
+				}
+			}
+
+			entryNames = uniqueNames(append(entryNames, falseNames...))
+			for _, expr := range caseClause.List {
+				falseNames = uniqueNames(append(falseNames, f.getNilCheckedNames(getSwitchCaseCond(s.Tag, expr), false)...))
+			}
+		}
+
+		clauseList = append(clauseList, caseClause)
+		entryNameList = append(entryNameList, entryNames)
+	}
+
+	if defaultIndex >= 0 {
+		entryNameList[defaultIndex] = falseNames
+	}
+
+	for i, caseClause := range clauseList {
+		if i > 0 && IsFallthroughClause(clauseList[i-1]) {
+			entryNameList[i] = nil
+		}
+
+		f.recordScopedCheckedNames(entryNameList[i], caseClause.Colon, caseClause.End(), "switch case condition")
+		f.recordBlockNilValidation(caseClause.Body, caseClause.End(), fset, lintErrors, typeInfo)
+	}
+
+	// 所有未终止的分支结束时都已校验的变量, 在 switch 语句之后才视为已校验
+	var (
+		candidateNames []string
+		afterNames     []string
+	)
+
+	for _, names := range entryNameList {
+		candidateNames = append(candidateNames, names...)
+	}
+	candidateNames = append(candidateNames, f.changedNameList[changedIndex:]...)
+
+	for _, name := range uniqueNames(candidateNames) {
+		isChecked := defaultIndex >= 0 || containsName(falseNames, name) || f.isCheckedAt(name, s.Body.Lbrace)
+		for _, caseClause := range clauseList {
+			if IsFallthroughClause(caseClause) || IsTerminatingCaseClause(caseClause, f.pass.TypesInfo) {
+				continue
+			}
+
+			if !f.isCheckedAt(name, caseClause.End()) {
+				isChecked = false
+			}
+		}
+
+		if isChecked {
+			afterNames = append(afterNames, name)
+		}
+	}
+
+	f.recordScopedCheckedNames(afterNames, s.End()-1, f.blockEnd, "checked by every case of the switch statement")
+}
+
+// getSwitchCaseCond switch d { case nil: } 转换为 d == nil
+func getSwitchCaseCond(tag ast.Expr, expr ast.Expr) ast.Expr {
+	if tag == nil {
+		return expr
+	}
+
+	return &ast.BinaryExpr{
+		X:     tag,
+		OpPos: expr.Pos(),
+		Op:    token.EQL,
+		Y:     expr,
+	}
+}
+
+func (f *FuncDelChecker) recordRangeStmtNilValidation(s *ast.RangeStmt, typeInfo *types.Info, fset *token.FileSet, lintErrors *[]*LintError) {
+	var (
+		x               = s.X
+		rangeParentName string
+	)
+
+	switch expr := x.(type) {
+	case *ast.Ident:
+		rangeParentName = expr.Name
+		if _, ok := f.needCheckPointerPositionMap[rangeParentName]; ok {
+			value := s.Value
+			switch value.(type) {
+			case *ast.Ident:
+				f.recordRangeValue(s, fset)
+			}
+		}
+
+	case *ast.SelectorExpr:
+		innerX := expr.X
+		switch innerX := innerX.(type) {
+		case *ast.Ident:
+			rangeParentName = innerX.Name
+			if _, ok := f.needCheckPointerPositionMap[rangeParentName]; ok {
+				if IsPointerArray(expr.Sel, f.pass.TypesInfo) {
+					lintErrorList := f.detectSelectorReferenceWithFunc(expr, fset)
+					if len(lintErrorList) > 0 {
+						*lintErrors = append(*lintErrors, lintErrorList...)
+					}
+
+					f.recordRangeValue(s, fset)
+				}
+			}
+		}
+
+	case *ast.CallExpr:
+		if exprFun, ok := expr.Fun.(*ast.SelectorExpr); ok {
+			lintErrorList := f.detectSelectorReferenceWithFunc(exprFun, fset)
+			if len(lintErrorList) > 0 {
+				*lintErrors = append(*lintErrors, lintErrorList...)
+			}
+		}
+
+		var sign = GetFuncSignature(expr, typeInfo)
+		if sign == nil {
+			return
+		}
+
+		if sign.Results().Len() != 1 { // for range ch() 之外, 多返回值或无返回值的调用
+			return
+		}
+
+		retType := sign.Results().At(0).Type()
+		if IsContainerIncludePointerElem(retType) {
+			f.recordRangeValue(s, fset)
+		}
+	}
+
+	body := s.Body
+	if body == nil {
+		return
+	}
+
+	f.recordBlockNilValidation(body.List, body.Rbrace, fset, lintErrors, typeInfo)
+}
+
+func (f *FuncDelChecker) recordRangeValue(s *ast.RangeStmt, fset *token.FileSet) {
+	value := s.Value
+	if v, ok := value.(*ast.Ident); ok {
+		var pos token.Position
+		GetIdentPosition(&pos, v, fset)
+		f.removeAlias(v.Name)
+		f.recordNeedCheckPosition(v.Name, pos, false, ReasonRangeValue)
+	}
+}
+
+func (f *FuncDelChecker) recordAssignmentStmtNilValidation(s *ast.AssignStmt, typeInfo *types.Info, fset *token.FileSet) {
+	var respVarNameList = make([]string, 0)
+	for _, l := range s.Lhs {
+		if i, ok := l.(*ast.Ident); ok {
+			respVarNameList = append(respVarNameList, i.Name)
+		}
+	}
+
+	f.removeErrorPairedResult(s)
+	f.removeOkPairedResult(s)
+	for rhsIndex, expr := range s.Rhs {
+		f.recordShortCircuitGuards(expr)
+
+		switch EX := expr.(type) {
+		case *ast.IndexExpr, *ast.TypeAssertExpr, *ast.UnaryExpr:
+			f.recordElemValueAssignment(s, rhsIndex, EX, typeInfo, fset)
+
+		case *ast.CallExpr:
+			sign := GetFuncSignature(EX, typeInfo)
+			if sign == nil {
+				continue
+			}
+
+			f.recordErrorPairedResult(s, sign, fset)
+
+			respVarNameListLength := len(respVarNameList)
+			if respVarNameListLength > 0 && sign != nil && sign.Results() != nil && respVarNameListLength == sign.Results().Len() {
+				for index, varName := range respVarNameList {
+					retType := sign.Results().At(index).Type()
+					pos := fset.Position(EX.Rparen)
+					var (
+						needCheckedPos *CheckPointerPosition
+						isNeedRecord   bool
+					)
+					if IsContainerIncludePointerElem(retType) {
+						needCheckedPos = &CheckPointerPosition{
+							Line:      pos.Line,
+							Colum:     pos.Column,
+							IsChecked: true,
+							Reason:    "container result of a call",
+						}
+						isNeedRecord = true
+					}
+
+					if IsPointer(retType) {
+						needCheckedPos = &CheckPointerPosition{
+							Line:      pos.Line,
+							Colum:     pos.Column,
+							IsChecked: false,
+							Reason:    ReasonCallResult,
+						}
+						isNeedRecord = true
+					}
+
+					if isNeedRecord {
+						f.insertCheckPointerPosition(varName, needCheckedPos)
+					}
+				}
+			}
+			f.recordCallKill(EX, typeInfo, fset.Position(s.End()))
+		}
+	}
+
+	f.recordAssignmentKill(s, typeInfo, fset)
+}
+
+// recordAssignmentKill 变量或其父节点重新赋值后, 之前的校验失效; 赋值为其他变量时, 两者互为别名
+//
+//	d.A = nil         // d.A 需要重新校验
+//	d = GetDataInfo() // d, d.A 需要重新校验
+//	d = &DataInfo{}   // d 非 nil, d.A 需要重新校验
+//	a, b := d, d.A    // a 与 d, b 与 d.A 互为别名
+func (f *FuncDelChecker) recordAssignmentKill(s *ast.AssignStmt, typeInfo *types.Info, fset *token.FileSet) {
+	if s.Tok != token.ASSIGN && s.Tok != token.DEFINE {
+		return
+	}
+
+	var (
+		pos          = fset.Position(s.End())
+		lhsNameList  = make([]string, len(s.Lhs))
+		rhsNameList  = make([]string, len(s.Lhs))
+		rhsStateList = make([]int, len(s.Lhs))
+	)
+
+	// 先计算右侧表达式, 再修改左侧变量, 如 a, b = b, a
+	for i, lhs := range s.Lhs {
+		lhsNameList[i] = GetAccessPathName(lhs)
+		if len(s.Lhs) != len(s.Rhs) {
+			if i == 0 && len(s.Rhs) == 1 && IsTypeAssertExpr(s.Rhs[0]) { // v, ok := x.(*T), v 与 x.(*T) 互为别名
+				rhsNameList[i] = GetAccessPathName(s.Rhs[0])
+			}
+			rhsStateList[i] = assignUnknown
+			continue
+		}
+
+		rhs := s.Rhs[i]
+		if rhsName := GetAccessPathName(rhs); rhsName != "" && f.isRootComeFromOutside(rhsName) {
+			rhsNameList[i] = rhsName
+		}
+
+		switch {
+		case IsNilIdent(rhs):
+			rhsStateList[i] = assignNil
+		case f.isNonNilExpr(rhs, typeInfo):
+			rhsStateList[i] = assignNonNil
+		default:
+			rhsStateList[i] = assignUnknown
+		}
+	}
+
+	for i, lhs := range s.Lhs {
+		name := lhsNameList[i]
+		if name == "" || name == "_" {
+			continue
+		}
+
+		f.removeAlias(name)
+		f.recordFieldKill(name, pos)
+		if !IsNilable(typeInfo.TypeOf(lhs)) {
+			continue
+		}
+
+		isTracked := f.isRootComeFromOutside(name) || rhsNameList[i] != ""
+		switch {
+		case rhsStateList[i] == assignNil:
+			f.recordNeedCheckPosition(name, pos, false, ReasonAssignNil)
+
+		case rhsStateList[i] == assignNonNil:
+			if isTracked {
+				f.recordScopedCheckedNames([]string{name}, s.End(), f.blockEnd, "assigned a non-nil value")
+			}
+
+		case isTracked:
+			f.recordNeedCheckPosition(name, pos, false, GetAssignReason(s, i, f.pass.Pkg, typeInfo))
+		}
+
+		if rhsNameList[i] != "" && !containsRootName(lhsNameList, rhsNameList[i]) {
+			f.addAlias(name, rhsNameList[i])
+		}
+	}
+}
+
+const (
+	assignUnknown int = 0
+	assignNil     int = 1
+	assignNonNil  int = 2
+)
+
+// containsRootName 判断 name 的根节点是否在 rootNames 中, 如 a, b = b, a
+func containsRootName(rootNames []string, name string) bool {
+	return containsName(rootNames, strings.Split(name, ".")[0])
+}
+
+// addAlias 记录指向同一对象的变量, 如 a := d, n := d.A
+func (f *FuncDelChecker) addAlias(name, aliasName string) {
+	aliasList := f.aliasMap[aliasName]
+	if len(aliasList) == 0 {
+		aliasList = []string{aliasName}
+	}
+
+	aliasList = uniqueNames(append(append([]string{}, aliasList...), name))
+	for _, n := range aliasList {
+		if _, ok := f.aliasMap[n]; !ok {
+			AddNameIndex(f.aliasNameIndexMap, n)
+		}
+		f.aliasMap[n] = aliasList
+	}
+}
+
+// removeAlias name 重新赋值后, name 及其子节点不再是其他变量的别名
+func (f *FuncDelChecker) removeAlias(name string) {
+	prefix := name + "."
+	for _, key := range GetIndexedNames(f.aliasNameIndexMap, f.aliasMap, name) {
+		aliasList, ok := f.aliasMap[key]
+		if !ok || key != name && !strings.HasPrefix(key, prefix) {
+			continue
+		}
+
+		var newAliasList []string
+		for _, n := range aliasList {
+			if n != key {
+				newAliasList = append(newAliasList, n)
+			}
+		}
+
+		for _, n := range newAliasList {
+			f.aliasMap[n] = newAliasList
+		}
+		delete(f.aliasMap, key)
+	}
+}
+
+// getAliasNames 获取 name 的别名, 如 a := d 时 d.A 的别名为 a.A
+func (f *FuncDelChecker) getAliasNames(name string) []string {
+	if len(f.aliasMap) == 0 {
+		return nil
+	}
+
+	var (
+		aliasNames []string
+		nodes      = strings.Split(name, ".")
+	)
+
+	for i := 1; i <= len(nodes); i++ {
+		prefix := strings.Join(nodes[:i], ".")
+		suffix := strings.TrimPrefix(name, prefix)
+		for _, aliasName := range f.aliasMap[prefix] {
+			if aliasName != prefix {
+				aliasNames = append(aliasNames, aliasName+suffix)
+			}
+		}
+	}
+
+	return uniqueNames(aliasNames)
+}
+
+// recordFieldKill name 重新赋值或可能被修改后, 其子节点 name.X 的校验失效
+func (f *FuncDelChecker) recordFieldKill(name string, pos token.Position) {
+	var (
+		prefix    = name + "."
+		indexName = GetIndexNodeName(&ast.Ident{Name: name}) // 下标变量重新赋值, 如 i = j 之后 items[i] 需要重新校验
+	)
+	for _, key := range GetIndexedNames(f.recordNameIndexMap, f.needCheckPointerPositionMap, name) {
+		if strings.HasPrefix(key, prefix) || strings.Contains(key, indexName) {
+			f.recordNeedCheckPosition(key, pos, false, "parent reassigned or possibly modified")
+		}
+	}
+}
+
+// recordCallKill conservative-fields 模式下, 接收结构体指针的调用之后, 结构体字段的校验失效, 如 d.Reset(), reset(d)
+func (f *FuncDelChecker) recordCallKill(call *ast.CallExpr, typeInfo *types.Info, pos token.Position) {
+	if !conservativeFields || call == nil {
+		return
+	}
+
+	var nameList []string
+	if sel, ok := call.Fun.(*ast.SelectorExpr); ok {
+		if fn, ok := typeInfo.ObjectOf(sel.Sel).(*types.Func); ok {
+			if sign, ok := fn.Type().(*types.Signature); ok && sign.Recv() != nil && IsPointer(sign.Recv().Type()) {
+				nameList = append(nameList, GetAccessPathName(sel.X))
+			}
+		}
+	}
+
+	for _, arg := range call.Args {
+		if unary, ok := arg.(*ast.UnaryExpr); ok && unary.Op == token.AND { // reset(&d)
+			nameList = append(nameList, GetAccessPathName(unary.X))
+		} else if IsPointer(typeInfo.TypeOf(arg)) {
+			nameList = append(nameList, GetAccessPathName(arg))
+		}
+	}
+
+	for _, name := range nameList {
+		if name != "" {
+			f.recordFieldKill(name, pos)
+		}
+	}
+}
+
+// isNonNilExpr 判断赋值表达式是否一定非 nil, 如 &T{}, new(T), 以及非外部来源的变量
+func (f *FuncDelChecker) isNonNilExpr(expr ast.Expr, typeInfo *types.Info) bool {
+	switch ex := expr.(type) {
+	case *ast.ParenExpr:
+		return f.isNonNilExpr(ex.X, typeInfo)
+
+	case *ast.UnaryExpr:
+		return ex.Op == token.AND
+
+	case *ast.CompositeLit, *ast.FuncLit:
+		return true
+
+	case *ast.CallExpr:
+		if ident, ok := ex.Fun.(*ast.Ident); ok {
+			if _, ok := typeInfo.ObjectOf(ident).(*types.Builtin); ok {
+				return ident.Name == "new" || ident.Name == "make"
+			}
+		}
+
+		name := GetAccessPathName(ex.Fun)
+		nodes := strings.Split(name, ".")
+		return name != "" && IsFuncPtrRespNeedSkip(nodes[len(nodes)-1])
+
+	case *ast.Ident, *ast.SelectorExpr:
+		name := GetAccessPathName(ex)
+		if name == "" {
+			return false
+		}
+
+		if !f.isRootComeFromOutside(name) {
+			return true
+		}
+
+		return f.isCheckedAt(name, ex.Pos())
+	}
+
+	return false
+}
+
+// recordElemValueAssignment 记录通过 map 取值、下标取值、类型断言、channel 接收得到的指针变量,
+// 如 v := m[k], v, ok := m[k], p, _ := x.(*T), p := <-ch, p := list[i]; _, ok := x.(*T) 校验 ok 之后 x.(*T) 视为已校验
+func (f *FuncDelChecker) recordElemValueAssignment(s *ast.AssignStmt, rhsIndex int, expr ast.Expr, typeInfo *types.Info, fset *token.FileSet) {
+	var valueIdent, okIdent *ast.Ident
+	if len(s.Lhs) == len(s.Rhs) {
+		valueIdent, _ = s.Lhs[rhsIndex].(*ast.Ident)
+	} else if len(s.Rhs) == 1 && len(s.Lhs) == 2 { // comma-ok
+		valueIdent, _ = s.Lhs[0].(*ast.Ident)
+		okIdent, _ = s.Lhs[1].(*ast.Ident)
+	}
+
+	isValueNilable := valueIdent != nil && valueIdent.Name != "_" && IsNilable(GetElemValueType(expr, typeInfo))
+	if isValueNilable {
+		reason := ReasonElemValue
+		if IsPackageTableElem(expr, f.pass.Pkg, typeInfo) {
+			reason = ReasonTableValue
+		}
+		f.recordNeedCheckPosition(valueIdent.Name, fset.Position(expr.End()), false, reason)
+	}
+
+	if okIdent == nil || okIdent.Name == "_" {
+		return
+	}
+
+	if isValueNilable {
+		f.okVarMap[okIdent.Name] = valueIdent.Name
+	} else if assertName := GetAccessPathName(expr); assertName != "" && IsTypeAssertExpr(expr) {
+		f.okVarMap[okIdent.Name] = assertName
+	}
+}
+
+// GetAssignReason 第 i 个变量赋值为可能为 nil 的值时的记录来源, 如 u = find(), u = m[k]
+func GetAssignReason(s *ast.AssignStmt, i int, pkg *types.Package, typeInfo *types.Info) string {
+	rhs := s.Rhs[0]
+	if len(s.Lhs) == len(s.Rhs) {
+		rhs = s.Rhs[i]
+	}
+
+	if _, ok := rhs.(*ast.CallExpr); ok {
+		return ReasonCallResult
+	}
+
+	if IsTypeAssertExpr(rhs) && len(s.Lhs) == len(s.Rhs) { // 单值类型断言失败时 panic, 不会得到 nil
+		return "assigned a possibly nil value"
+	}
+
+	if IsPackageTableElem(rhs, pkg, typeInfo) {
+		return ReasonTableValue
+	}
+
+	if GetElemValueType(rhs, typeInfo) != nil {
+		return ReasonElemValue
+	}
+
+	return "assigned a possibly nil value"
+}
+
+// IsPackageTableElem 判断是否为包级别切片或数组变量的元素, 如 Typ[Invalid]; map 取值除外
+func IsPackageTableElem(expr ast.Expr, pkg *types.Package, typeInfo *types.Info) bool {
+	indexExpr, ok := expr.(*ast.IndexExpr)
+	if !ok {
+		return false
+	}
+
+	ident, ok := indexExpr.X.(*ast.Ident)
+	if !ok {
+		return false
+	}
+
+	obj, ok := typeInfo.ObjectOf(ident).(*types.Var)
+	if !ok || obj.Parent() != pkg.Scope() {
+		return false
+	}
+
+	_, isMap := obj.Type().Underlying().(*types.Map)
+	return !isMap
+}
+
+// IsTypeAssertExpr 判断是否为类型断言 x.(T), 不包括 type switch 中的 x.(type)
+func IsTypeAssertExpr(expr ast.Expr) bool {
+	assertExpr, ok := expr.(*ast.TypeAssertExpr)
+	return ok && assertExpr.Type != nil
+}
+
+// recordErrorPairedResult 记录 (*T, error) 返回值中 error 变量与指针变量的对应关系, u, err := repo.Find(id)
+func (f *FuncDelChecker) recordErrorPairedResult(s *ast.AssignStmt, sign *types.Signature, fset *token.FileSet) {
+	if !errorPairedResult || len(s.Lhs) != 2 || sign.Results() == nil || sign.Results().Len() != 2 {
+		return
+	}
+
+	if !IsPointer(sign.Results().At(0).Type()) || !IsErrorType(sign.Results().At(1).Type()) {
+		return
+	}
+
+	valueIdent, ok := s.Lhs[0].(*ast.Ident)
+	if !ok || valueIdent.Name == "_" {
+		return
+	}
+
+	errIdent, ok := s.Lhs[1].(*ast.Ident)
+	if !ok || errIdent.Name == "_" {
+		return
+	}
+
+	f.errVarMap[errIdent.Name] = valueIdent.Name
+	f.errPairedPositionMap[valueIdent.Name] = fset.Position(s.End())
+	delete(f.errCheckedPositionMap, valueIdent.Name)
+}
+
+// removeErrorPairedResult err 或指针变量被重新赋值后不再对应, 如 err = validate(), _, err = other(), 由 recordErrorPairedResult 重新记录
+func (f *FuncDelChecker) removeErrorPairedResult(s *ast.AssignStmt) {
+	for _, lhs := range s.Lhs {
+		ident, ok := lhs.(*ast.Ident)
+		if !ok || ident.Name == "_" {
+			continue
+		}
+
+		delete(f.errVarMap, ident.Name)
+		for errName, valueName := range f.errVarMap {
+			if valueName == ident.Name {
+				delete(f.errVarMap, errName)
+			}
+		}
+	}
+}
+
+// removeOkPairedResult ok 或取值变量被重新赋值后不再对应, 如 _, ok = m2[k], ok = other != nil, 由 recordElemValueAssignment 重新记录
+func (f *FuncDelChecker) removeOkPairedResult(s *ast.AssignStmt) {
+	for _, lhs := range s.Lhs {
+		ident, ok := lhs.(*ast.Ident)
+		if !ok || ident.Name == "_" {
+			continue
+		}
+
+		delete(f.okVarMap, ident.Name)
+		for okName, valueName := range f.okVarMap {
+			if valueName == ident.Name || strings.HasPrefix(valueName, ident.Name+".") {
+				delete(f.okVarMap, okName)
+			}
+		}
+	}
+}
+
+func (f *FuncDelChecker) recordNeedCheckPosition(name string, pos token.Position, isChecked bool, reason string) {
+	f.insertCheckPointerPosition(name, &CheckPointerPosition{
+		Line:      pos.Line,
+		Colum:     pos.Column,
+		IsChecked: isChecked,
+		Reason:    reason,
+	})
+}
+
+type LintError struct {
+	Message string
+	File    string
+	Line    int
+	Colum   int
+}
+
+const (
+	NPEMessageTipInfo        = "potential nil pointer reference"
+	NPEBeforeErrCheckTipInfo = "potential nil pointer reference before checking the paired error"
+	NPEFuncCallTipInfo       = "potential nil function call"
+	NPENilMapWriteTipInfo    = "potential nil map write"
+	NPETypeAssertTipInfo     = "unchecked type assertion"
+	NPETypedNilTipInfo       = "potential nil pointer stored in a non-nil interface"
+	NPEInternalErrorTipInfo  = "npecheck internal error"
+	NPESkippedTipInfo        = "npecheck skipped"
+)
+
+// 实现 Error 方法
+func (err *LintError) Error() string {
+	return fmt.Sprintf("%s: %s:%d:%d", err.Message, err.File, err.Line, err.Colum)
+}
+
+func RemoveVarLeafNode(varName string) string {
+	if varName == "" {
+		return ""
+	}
+
+	parts := strings.Split(varName, ".")
+	var substr string
+	if len(parts) > 1 {
+		substr = strings.Join(parts[:len(parts)-1], ".")
+	}
+
+	return substr
+}
+
+type SelectNode struct {
+	Name               string
+	Type               int // NodeTypeDefaultSinglePtr, NodeTypeNonSinglePtr
+	IsReturnSingleFunc bool
+	CurIdent           *ast.Ident
+	CurPos             token.Pos // 没有标识符的节点的位置, 如 items[0] 的 [, x.(*T) 的 *T
+	IsTypeAssert       bool      // 类型断言节点, 如 x.(*T), 断言成功时结果仍可能为 nil
+}
+
+const (
+	NodeTypeDefaultSinglePtr int = 0
+	NodeTypeNonSinglePtr     int = 1
+)
+
+func buildFieldNameFromNodes(nodes []*SelectNode) string {
+	fieldNameList := make([]string, 0)
+	for _, n := range nodes {
+		fieldNameList = append(fieldNameList, n.Name)
+	}
+
+	return strings.Join(fieldNameList, ".")
+}
+
+func walkSelectorWithFunc(expr *ast.SelectorExpr, fset *token.FileSet, walkFunc func(ast.Node)) {
+	walkFunc(expr)
+	walkSelectorParentWithFunc(expr.X, fset, walkFunc)
+}
+
+// walkSelectorParentWithFunc 遍历选择器的父节点, 如 d.A.B 的 d.A, items[0].Name 的 items[0]
+func walkSelectorParentWithFunc(expr ast.Expr, fset *token.FileSet, walkFunc func(ast.Node)) {
+	switch ex := expr.(type) {
+	case *ast.Ident:
+		walkFunc(ex)
+		return
+
+	case *ast.SelectorExpr:
+		walkSelectorWithFunc(ex, fset, walkFunc)
+
+	case *ast.IndexExpr:
+		walkFunc(ex)
+		walkSelectorParentWithFunc(ex.X, fset, walkFunc)
+
+	case *ast.TypeAssertExpr:
+		walkFunc(ex)
+		walkSelectorParentWithFunc(ex.X, fset, walkFunc)
+
+	case *ast.CallExpr:
+		fun := UnwrapFuncInstance(ex.Fun)
+		if se, ok := fun.(*ast.SelectorExpr); ok {
+			walkSelectorWithFunc(se, fset, walkFunc)
+		}
+
+		if se, ok := fun.(*ast.Ident); ok {
+			walkFunc(se)
+			return
+		}
+	}
+}
+
+// GetAccessPathName 获取变量访问路径, 如 d.A.B, d.GetChildNodeNonPtr().GetGrandsonNodePtr 为 d.GetChildNodeNonPtr.GetGrandsonNodePtr,
+// d.Items[i] 为 d.Items.[i], x.(*T) 为 x.(*T)
+func GetAccessPathName(expr ast.Expr) string {
+	switch ex := expr.(type) {
+	case *ast.Ident:
+		return ex.Name
+
+	case *ast.ParenExpr:
+		return GetAccessPathName(ex.X)
+
+	case *ast.SelectorExpr:
+		parentName := GetAccessPathName(ex.X)
+		if parentName == "" {
+			return ""
+		}
+		return parentName + "." + ex.Sel.Name
+
+	case *ast.IndexExpr:
+		parentName := GetAccessPathName(ex.X)
+		if parentName == "" {
+			return ""
+		}
+		return parentName + "." + GetIndexNodeName(ex.Index)
+
+	case *ast.TypeAssertExpr:
+		parentName := GetAccessPathName(ex.X)
+		if parentName == "" || ex.Type == nil {
+			return ""
+		}
+		return parentName + "." + GetTypeAssertNodeName(ex.Type)
+
+	case *ast.CallExpr:
+		return GetAccessPathName(UnwrapFuncInstance(ex.Fun))
+	}
+
+	return ""
+}
+
+// GetTypeAssertNodeName 获取类型断言在访问路径中的节点名, 如 x.(*User) 为 x.(*User), x.(*pkg.User) 为 x.(*pkg_User)
+func GetTypeAssertNodeName(typ ast.Expr) string {
+	return "(" + strings.ReplaceAll(types.ExprString(typ), ".", "_") + ")"
+}
+
+// GetIndexNodeName 获取容器元素在访问路径中的节点名, 如 items[0] 为 items.[0], 下标中的 . 替换为 _, 如 byID[d.ID] 为 byID.[d_ID]
+func GetIndexNodeName(index ast.Expr) string {
+	return "[" + strings.ReplaceAll(types.ExprString(index), ".", "_") + "]"
+}
+
+func uniqueNames(names []string) []string {
+	var (
+		result  []string
+		nameMap = make(map[string]bool, len(names))
+	)
+
+	for _, name := range names {
+		if !nameMap[name] {
+			nameMap[name] = true
+			result = append(result, name)
+		}
+	}
+	return result
+}
+
+func intersectNames(names1, names2 []string) []string {
+	var result []string
+	for _, name := range names1 {
+		if containsName(names2, name) {
+			result = append(result, name)
+		}
+	}
+	return result
+}
+
+// reverseSlice 原地反转, 选择器从叶子节点向根节点遍历, 反转后根节点在前
+func reverseSlice[T any](list []T) {
+	for i, j := 0, len(list)-1; i < j; i, j = i+1, j-1 {
+		list[i], list[j] = list[j], list[i]
+	}
+}
+
+func containsName(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (f *FuncDelChecker) getPotentialNilPointerReference(
+	varName string,
+	filePath string,
+	pos *token.Position,
+	reportPos token.Pos,
+	isComeFromOutSide bool,
+) *LintError {
+	if pos == nil {
+		return nil
+	}
+
+	refName := RemoveVarLeafNode(varName)
+	if refName == "" {
+		return nil
+	}
+
+	//needCheckInfo, ok := needCheckPointerPositionMap[refName]
+	index := f.findFirstSuitablePosIndexFromEnd(refName, *pos)
+
+	message := f.getNilPointerMessage(refName, *pos)
+	if isComeFromOutSide && index < 0 {
+		f.report(reportPos, message)
+		return &LintError{
+			Message: message,
+			File:    filePath,
+			Line:    pos.Line,
+			Colum:   pos.Column,
+		}
+	}
+
+	if index >= 0 {
+		if lintError := f.buildLintError(f.needCheckPointerPositionMap[refName][index], filePath, pos); lintError != nil {
+			lintError.Message = message
+			f.report(reportPos, message)
+			return lintError
+		}
+	}
+
+	return nil
+}
+
+// report 同一位置只报告一次, isSilent 时只检测不报告
+func (f *FuncDelChecker) report(pos token.Pos, message string) {
+	if f.isSilent || f.reportedPosMap[pos] {
+		return
+	}
+
+	f.reportedPosMap[pos] = true
+	f.diagnostics = append(f.diagnostics, analysis.Diagnostic{Pos: pos, Message: message})
+}
+
+// flushDiagnostics 函数检测完成后统一报告, 检测中途 panic 或超时的函数不报告
+func (f *FuncDelChecker) flushDiagnostics() {
+	for _, diagnostic := range f.diagnostics {
+		f.pass.Report(diagnostic)
+	}
+	f.diagnostics = nil
+}
+
+// errFuncTimeout 检测耗时超过 -func-timeout
+var errFuncTimeout = errors.New("exceeded -func-timeout")
+
+// checkDeadline 超过 -func-timeout 时终止当前函数的检测
+func (f *FuncDelChecker) checkDeadline() {
+	if !f.deadline.IsZero() && time.Now().After(f.deadline) {
+		panic(errFuncTimeout)
+	}
+}
+
+// getNilPointerMessage resp, err := client.Do(req); defer resp.Body.Close(), 在校验 err 之前引用 resp 单独提示
+func (f *FuncDelChecker) getNilPointerMessage(refName string, pos token.Position) string {
+	rootName := strings.Split(refName, ".")[0]
+	for _, valueName := range f.errVarMap {
+		if valueName != rootName {
+			continue
+		}
+
+		// u 在 u, err := repo.Find(id) 之前的引用与 err 无关
+		pairedPos := f.errPairedPositionMap[rootName]
+		if pos.Line < pairedPos.Line || (pos.Line == pairedPos.Line && pos.Column < pairedPos.Column) {
+			continue
+		}
+
+		checkedPos, ok := f.errCheckedPositionMap[rootName]
+		if !ok || checkedPos.Line > pos.Line || (checkedPos.Line == pos.Line && checkedPos.Column > pos.Column) {
+			return NPEBeforeErrCheckTipInfo
+		}
+	}
+
+	return NPEMessageTipInfo
+}
+
+func (f *FuncDelChecker) buildLintError(needCheckInfo *CheckPointerPosition, filePath string, pos *token.Position) *LintError {
+	lintErr := &LintError{
+		Message: NPEMessageTipInfo,
+		File:    filePath,
+		Line:    pos.Line,
+		Colum:   pos.Column,
+	}
+
+	if needCheckInfo == nil {
+		return lintErr
+	}
+
+	if needCheckInfo.IsChecked == false {
+		return lintErr
+	}
+
+	if needCheckInfo.Line > pos.Line || (needCheckInfo.Line == pos.Line && needCheckInfo.Colum > pos.Column) {
+		return lintErr
+	}
+	return nil
+}
+
+func Run(pass *analysis.Pass) (interface{}, error) {
+	var (
+		fset          = pass.Fset
+		lintErrorList = make([]*LintError, 0)
+	)
+
+	pkgContext, err := newPackageContext(pass)
+	if err != nil {
+		return nil, err
+	}
+
+	explainTarget, err := ParseExplainTarget(explainPos)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, file := range pass.Files {
+		if pkgContext.isSkippedFile(file) {
+			continue
+		}
+
+		for _, decl := range file.Decls {
+			switch decl := decl.(type) {
+			case *ast.GenDecl: // var name = db.Name
+				CheckSafely(pass, "package-level var", decl.Pos(), func() {
+					checker := pkgContext.newFuncChecker()
+					checker.detectGlobalVarInitializer(decl, &lintErrorList)
+					checker.flushDiagnostics()
+				})
+
+			case *ast.FuncDecl:
+				if decl.Body == nil { // 汇编实现的函数
+					continue
+				}
+
+				funcName := GetFuncDeclName(decl, pass.TypesInfo)
+				if maxFuncNodes > 0 {
+					if nodeCount := CountNodes(decl.Body); nodeCount > maxFuncNodes {
+						pass.Reportf(decl.Pos(), "%s %s: %d AST nodes exceeds -max-func-nodes=%d", NPESkippedTipInfo, funcName, nodeCount, maxFuncNodes)
+						continue
+					}
+				}
+
+				CheckSafely(pass, funcName, decl.Pos(), func() {
+					checker := pkgContext.newFuncChecker()
+					checker.preRecordNilPointerFromOutside(decl, &lintErrorList)
+					checker.detectNilPointerReference(decl, fset, &lintErrorList)
+					if explainTarget.Contains(fset, decl) {
+						checker.explain(explainOutput, funcName, decl, explainTarget)
+					}
+					checker.flushDiagnostics()
 				})
 			}
+		}
+	}
+	// fmt.Println(lintErrorList)
+	return nil, nil
+}
 
-		case *ast.CallExpr:
-			switch exFunc := exprInner.Fun.(type) {
-			case *ast.SelectorExpr:
-				if exFunc != nil && exFunc.Sel != nil {
-					nodeType, isReturnSingleFunc := GetNodeType(exFunc.Sel, f.pass.TypesInfo)
-					nodeList = append(nodeList, &SelectNode{
-						Name:               exFunc.Sel.Name,
-						CurIdent:           exFunc.Sel,
-						Type:               nodeType,
-						IsReturnSingleFunc: isReturnSingleFunc})
+// packageContext 包级别的检测信息, 包内每个函数的 FuncDelChecker 共用
+type packageContext struct {
+	pass *analysis.Pass
+
+	constructorFieldMap map[*types.TypeName]map[string]bool
+	nilableGlobalMap    map[*types.Var]bool
+	configuredGuardMap  map[string]*GuardFact
+	guardFuncMap        map[*types.Func]*GuardFact
+}
+
+// newPackageContext 计算包级别的检测信息, 并导出全局变量和校验函数的 fact
+func newPackageContext(pass *analysis.Pass) (*packageContext, error) {
+	configuredGuardMap, err := ParseGuardFuncs(guardFuncs)
+	if err != nil {
+		return nil, err
+	}
+
+	pkgContext := &packageContext{
+		pass:               pass,
+		nilableGlobalMap:   GetNilableGlobalMap(pass),
+		configuredGuardMap: configuredGuardMap,
+		guardFuncMap:       GetGuardFuncMap(pass),
+	}
+
+	if receiverFields {
+		pkgContext.constructorFieldMap = GetConstructorFieldMap(pass)
+	}
+
+	for obj := range pkgContext.nilableGlobalMap {
+		pass.ExportObjectFact(obj, &NilableGlobalFact{})
+	}
+
+	for fn, fact := range pkgContext.guardFuncMap {
+		pass.ExportObjectFact(fn, fact)
+	}
+
+	return pkgContext, nil
+}
+
+// isSkippedFile 未开启 -tests 时的测试文件, 生成的代码, 以及 -exclude 中的文件
+func (c *packageContext) isSkippedFile(file *ast.File) bool {
+	var fileName = c.pass.Fset.PositionFor(file.Pos(), false).Filename
+	if !includeTests && strings.HasSuffix(fileName, "_test.go") {
+		return true
+	}
+
+	if !strings.HasSuffix(fileName, ".go") { // go test 生成的 testmain
+		return true
+	}
+
+	if !includeGenerated && IsGeneratedFile(file) {
+		return true
+	}
+
+	return IsExcludedPath(fileName, excludePaths)
+}
+
+// newFuncChecker 创建检测单个函数的 FuncDelChecker
+func (c *packageContext) newFuncChecker() *FuncDelChecker {
+	checker := InitFuncDelChecker(c.pass)
+	if funcTimeout > 0 {
+		checker.deadline = time.Now().Add(funcTimeout)
+	}
+
+	checker.constructorFieldMap = c.constructorFieldMap
+	checker.nilableGlobalMap = c.nilableGlobalMap
+	checker.configuredGuardMap = c.configuredGuardMap
+	checker.guardFuncMap = c.guardFuncMap
+	return checker
+}
+
+// CheckSafely 检测单个函数, 超时或发生 panic 时跳过该函数并提示, 不影响包内其他函数的检测
+func CheckSafely(pass *analysis.Pass, name string, pos token.Pos, check func()) {
+	defer func() {
+		r := recover()
+		if r == nil {
+			return
+		}
+
+		if r == errFuncTimeout {
+			pass.Reportf(pos, "%s %s: %v=%v", NPESkippedTipInfo, name, r, funcTimeout)
+			return
+		}
+
+		pass.Reportf(pos, "%s in %s: %v", NPEInternalErrorTipInfo, name, r)
+	}()
+
+	check()
+}
+
+// ExplainTarget -explain 指定的位置, Column 为 0 时表示整行
+type ExplainTarget struct {
+	File   string
+	Line   int
+	Column int
+}
+
+// ParseExplainTarget 解析 -explain 参数, 格式为 file.go:LINE[:COL]
+func ParseExplainTarget(value string) (*ExplainTarget, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	parts := strings.Split(value, ":")
+	if len(parts) < 2 || len(parts) > 3 || parts[0] == "" {
+		return nil, fmt.Errorf("invalid -explain position %q, want file.go:LINE[:COL]", value)
+	}
+
+	target := &ExplainTarget{File: filepath.ToSlash(parts[0])}
+	line, err := strconv.Atoi(parts[1])
+	if err != nil || line <= 0 {
+		return nil, fmt.Errorf("invalid line in -explain position %q", value)
+	}
+	target.Line = line
+
+	if len(parts) == 3 {
+		column, err := strconv.Atoi(parts[2])
+		if err != nil || column <= 0 {
+			return nil, fmt.Errorf("invalid column in -explain position %q", value)
+		}
+		target.Column = column
+	}
+
+	return target, nil
+}
+
+// Contains 判断函数是否包含该位置, 文件名可以是路径的后缀, 如 a.go, pkg/a.go
+func (t *ExplainTarget) Contains(fset *token.FileSet, node ast.Node) bool {
+	if t == nil {
+		return false
+	}
+
+	var (
+		start    = fset.Position(node.Pos())
+		end      = fset.Position(node.End())
+		fileName = filepath.ToSlash(start.Filename)
+	)
+
+	if fileName != t.File && !strings.HasSuffix(fileName, "/"+t.File) {
+		return false
+	}
+
+	return start.Line <= t.Line && t.Line <= end.Line
+}
+
+// IsAt 判断节点是否在该位置, 未指定列时为整行
+func (t *ExplainTarget) IsAt(fset *token.FileSet, node ast.Node) bool {
+	start, end := fset.Position(node.Pos()), fset.Position(node.End())
+	if t.Column == 0 {
+		return start.Line == t.Line
+	}
+
+	return start.Line == t.Line && start.Column <= t.Column && (end.Line > t.Line || t.Column < end.Column)
+}
+
+// explain 输出 target 处的访问路径、来源, 以及每个路径节点的校验记录是否生效
+func (f *FuncDelChecker) explain(w io.Writer, funcName string, decl *ast.FuncDecl, target *ExplainTarget) {
+	var (
+		fset      = f.pass.Fset
+		pathList  []string
+		posMap    = make(map[string]token.Position)
+		targetPos = fmt.Sprintf("%s:%d", target.File, target.Line)
+	)
+
+	if target.Column > 0 {
+		targetPos += fmt.Sprintf(":%d", target.Column)
+	}
+
+	// 只取最外层的变量访问路径, 其前缀路径在输出时逐个展开; 跳过包名、方法和函数
+	ast.Inspect(decl.Body, func(n ast.Node) bool {
+		expr, ok := n.(ast.Expr)
+		if !ok || !target.IsAt(fset, expr) {
+			return true
+		}
+
+		switch node := expr.(type) {
+		case *ast.Ident:
+			if _, ok := f.pass.TypesInfo.ObjectOf(node).(*types.Var); !ok {
+				return true
+			}
+		case *ast.SelectorExpr:
+			if selection, ok := f.pass.TypesInfo.Selections[node]; !ok || selection.Kind() != types.FieldVal {
+				return true
+			}
+		case *ast.IndexExpr, *ast.TypeAssertExpr:
+		default:
+			return true
+		}
+
+		name := GetAccessPathName(expr)
+		if _, ok := posMap[name]; name != "" && name != "_" && !ok {
+			posMap[name] = fset.Position(expr.End())
+			pathList = append(pathList, name)
+		}
+		return false
+	})
+
+	fmt.Fprintf(w, "npecheck: explain %s in %s\n", targetPos, funcName)
+	if len(pathList) == 0 {
+		fmt.Fprintf(w, "  no access paths at this position\n")
+	}
+
+	// 叶子节点本身不被解引用, 只展开到其父节点
+	for _, path := range pathList {
+		isComeFromOutside := f.isRootComeFromOutside(path)
+		fmt.Fprintf(w, "access path %s, root from outside: %t\n", path, isComeFromOutside)
+		nodes := strings.Split(path, ".")
+		for i := 1; i < len(nodes) || i == 1; i++ {
+			f.explainRecords(w, strings.Join(nodes[:i], "."), posMap[path], isComeFromOutside)
+		}
+	}
+
+	for _, diagnostic := range f.diagnostics {
+		if pos := fset.Position(diagnostic.Pos); pos.Line == target.Line {
+			fmt.Fprintf(w, "reported at %d:%d: %s\n", pos.Line, pos.Column, diagnostic.Message)
+		}
+	}
+}
+
+// explainRecords 输出 name 的校验记录, 以及在 pos 处是否生效
+func (f *FuncDelChecker) explainRecords(w io.Writer, name string, pos token.Position, isComeFromOutside bool) {
+	recordList := f.needCheckPointerPositionMap[name]
+	appliedIndex := f.findFirstSuitablePosIndexFromEnd(name, pos)
+
+	fmt.Fprintf(w, "  %s:\n", name)
+	switch {
+	case appliedIndex >= 0:
+	case isComeFromOutside:
+		fmt.Fprintf(w, "    no record applies, possibly nil since the root comes from outside\n")
+	default:
+		fmt.Fprintf(w, "    no record applies, not tracked\n")
+	}
+
+	for index, record := range recordList {
+		var (
+			state  = "unchecked"
+			scope  = fmt.Sprintf("%d:%d", record.Line, record.Colum)
+			reason = record.Reason
+			status string
+		)
+
+		if record.IsChecked {
+			state = "checked"
+		}
+
+		if record.EndLine > 0 {
+			scope += fmt.Sprintf("-%d:%d", record.EndLine, record.EndColum)
+		}
+
+		if reason == "" {
+			reason = "unknown"
+		}
+
+		switch {
+		case index == appliedIndex && record.IsChecked:
+			status = "applies, treated as non-nil"
+		case index == appliedIndex:
+			status = "applies, possibly nil"
+		case !record.IsBefore(pos.Line, pos.Column):
+			status = "ignored, recorded after the position"
+		case !record.IsInScope(pos):
+			status = "ignored, out of scope"
+		default:
+			status = "ignored, superseded by a later record"
+		}
+
+		fmt.Fprintf(w, "    %-15s %-9s %-6s %s: %s\n", scope, state, GetRecordTypeName(record.Type), reason, status)
+	}
+}
+
+// GetRecordTypeName 校验记录类型的名称, 用于 -explain
+func GetRecordTypeName(typ int) string {
+	switch typ {
+	case SlicePtrType:
+		return "elems"
+	case ParentPtrCurNonType:
+		return "field"
+	}
+	return "ptr"
+}
+
+// 参数和返回值的 nil 约束, 用于 npecheck contracts
+const (
+	ContractChecked       = "checked"                      // 引用前校验了 nil, 可以传入 nil
+	ContractDereferenced  = "dereferenced unconditionally" // 未经校验直接引用, 不能传入 nil
+	ContractPassedThrough = "passed through"               // 未引用, 只是传给其他函数、赋值或返回
+	ContractNeverNil      = "never nil"
+	ContractMayBeNil      = "may be nil"
+)
+
+// FuncContract 导出函数的指针参数和指针返回值的 nil 约束
+type FuncContract struct {
+	Package string           `json:"package"`
+	Func    string           `json:"func"`
+	File    string           `json:"file"`
+	Line    int              `json:"line"`
+	Params  []*ValueContract `json:"params,omitempty"`
+	Results []*ValueContract `json:"results,omitempty"`
+}
+
+// ValueContract 单个参数或返回值的 nil 约束, Index 为其在参数列表或返回值列表中的位置
+type ValueContract struct {
+	Index    int    `json:"index"`
+	Name     string `json:"name,omitempty"`
+	Type     string `json:"type"`
+	Contract string `json:"contract"`
+}
+
+// GetFuncContracts 获取包中导出函数和导出类型的导出方法的 nil 约束, 基于与检测相同的 FuncDelChecker 记录
+//
+//	func Find(db *DB) *User { if db == nil { return nil }; ... } // db: checked, result: may be nil
+func GetFuncContracts(pass *analysis.Pass) ([]*FuncContract, error) {
+	pkgContext, err := newPackageContext(pass)
+	if err != nil {
+		return nil, err
+	}
+
+	var contractList []*FuncContract
+	for _, file := range pass.Files {
+		if pkgContext.isSkippedFile(file) {
+			continue
+		}
+
+		for _, decl := range file.Decls {
+			decl, ok := decl.(*ast.FuncDecl)
+			if !ok || decl.Body == nil || !IsExportedFunc(decl, pass.TypesInfo) {
+				continue
+			}
+
+			funcName := GetFuncDeclName(decl, pass.TypesInfo)
+			if maxFuncNodes > 0 && CountNodes(decl.Body) > maxFuncNodes {
+				continue
+			}
+
+			CheckSafely(pass, funcName, decl.Pos(), func() {
+				var lintErrorList []*LintError
+				checker := pkgContext.newFuncChecker()
+				checker.preRecordNilPointerFromOutside(decl, &lintErrorList)
+				checker.detectNilPointerReference(decl, pass.Fset, &lintErrorList)
+
+				contract := checker.getFuncContract(decl)
+				if len(contract.Params) == 0 && len(contract.Results) == 0 {
+					return
 				}
 
-			case *ast.Ident:
-				nodeType, isReturnSingleFunc := GetNodeType(exFunc, f.pass.TypesInfo)
-				nodeList = append(nodeList, &SelectNode{Name: exFunc.Name,
-					CurIdent:           exFunc,
-					Type:               nodeType,
-					IsReturnSingleFunc: isReturnSingleFunc,
-				})
+				pos := pass.Fset.Position(decl.Pos())
+				contract.Package, contract.Func, contract.File, contract.Line = pass.Pkg.Path(), funcName, pos.Filename, pos.Line
+				contractList = append(contractList, contract)
+			})
+		}
+	}
+
+	return contractList, nil
+}
+
+// IsExportedFunc 导出函数, 或导出类型的导出方法
+func IsExportedFunc(decl *ast.FuncDecl, typeInfo *types.Info) bool {
+	fn, ok := typeInfo.Defs[decl.Name].(*types.Func)
+	if !ok || !fn.Exported() {
+		return false
+	}
+
+	recv := fn.Type().(*types.Signature).Recv()
+	if recv == nil {
+		return true
+	}
+
+	recvType := recv.Type()
+	if ptr, ok := recvType.(*types.Pointer); ok {
+		recvType = ptr.Elem()
+	}
+
+	named, ok := recvType.(*types.Named)
+	return ok && named.Obj().Exported()
+}
+
+// getFuncContract 在 preRecordNilPointerFromOutside 和 detectNilPointerReference 之后, 根据校验记录计算指针参数和返回值的约束
+func (f *FuncDelChecker) getFuncContract(decl *ast.FuncDecl) *FuncContract {
+	var (
+		contract   = &FuncContract{}
+		typeInfo   = f.pass.TypesInfo
+		qualifier  = types.RelativeTo(f.pass.Pkg)
+		returnList []*ast.ReturnStmt
+		derefMap   = make(map[types.Object]bool) // 未经校验直接引用的参数
+	)
+
+	fn, ok := typeInfo.Defs[decl.Name].(*types.Func)
+	if !ok {
+		return contract
+	}
+
+	// 闭包中的引用和返回不属于当前函数
+	ast.Inspect(decl.Body, func(n ast.Node) bool {
+		switch node := n.(type) {
+		case *ast.FuncLit:
+			return false
+
+		case *ast.ReturnStmt:
+			returnList = append(returnList, node)
+
+		case *ast.SelectorExpr: // p.Field, 值接收者的方法 p.Method()
+			if selection, ok := typeInfo.Selections[node]; ok && selection.Indirect() {
+				f.recordParamDeref(node.X, derefMap)
 			}
 
-		case *ast.Ident:
-			if exprInner != nil {
-				nodeType, isReturnSingleFunc := GetNodeType(exprInner, f.pass.TypesInfo)
-				nodeList = append(nodeList, &SelectNode{
-					Name:               exprInner.Name,
-					CurIdent:           exprInner,
-					Type:               nodeType,
-					IsReturnSingleFunc: isReturnSingleFunc})
-				sort.SliceStable(nodeList, func(i, j int) bool { // 反转数组
-					return i > j
-				})
+		case *ast.StarExpr: // *p
+			if tv, ok := typeInfo.Types[node]; ok && tv.IsValue() {
+				f.recordParamDeref(node.X, derefMap)
 			}
+		}
+		return true
+	})
 
-			var pos token.Position
-			GetIdentPosition(&pos, exprInner, fset)
-			fieldName := buildFieldNameFromNodes(nodeList)
-			//_, funcIndex := f.isExistFuncRetPtr(nodeList, false)
-			isRootFromOutside := f.isRootComeFromOutside(fieldName)
-			if isRootFromOutside /*|| isExistFunc*/ {
-				lintErrors := f.hasSequenceDetectNode(nodeList, pos, exprInner, isRootFromOutside)
-				if len(lintErrors) != 0 {
-					*lintErrorList = append(*lintErrorList, lintErrors...)
-				} else {
-					checkedPosition := &CheckPointerPosition{
-						Line:      pos.Line,
-						Colum:     pos.Column,
-						IsChecked: true,
-						Type:      DefaultPtrType,
-					}
+	sign := fn.Type().(*types.Signature)
+	for i := 0; i < sign.Params().Len(); i++ {
+		param := sign.Params().At(i)
+		if !IsPointer(param.Type()) || param.Name() == "" || param.Name() == "_" {
+			continue
+		}
 
-					leafNodeType := nodeList[len(nodeList)-1].Type
-					if leafNodeType == NodeTypeNonSinglePtr {
-						checkedPosition = &CheckPointerPosition{
-							Line:      0,
-							Colum:     0,
-							IsChecked: true,
-							Type:      ParentPtrCurNonType,
-						}
-					}
+		paramContract := ContractPassedThrough
+		if derefMap[param] {
+			paramContract = ContractDereferenced
+		} else if f.isCheckedParam(param.Name()) {
+			paramContract = ContractChecked
+		}
 
-					index := f.findFirstSuitablePosIndexFromStart(fieldName, pos)
-					if index >= 0 {
-						if f.needCheckPointerPositionMap[fieldName][index].IsChecked != true {
-							f.needCheckPointerPositionMap[fieldName][index] = checkedPosition
-						}
-					} else {
-						f.needCheckPointerPositionMap[fieldName] = []*CheckPointerPosition{checkedPosition}
-					}
+		contract.Params = append(contract.Params, &ValueContract{
+			Index:    i,
+			Name:     param.Name(),
+			Type:     types.TypeString(param.Type(), qualifier),
+			Contract: paramContract,
+		})
+	}
 
-					if originName, ok := f.originFieldMap[fieldName]; ok {
-						f.needCheckPointerPositionMap[originName] = f.needCheckPointerPositionMap[fieldName]
-					}
-				}
+	for i := 0; i < sign.Results().Len(); i++ {
+		result := sign.Results().At(i)
+		if !IsPointer(result.Type()) {
+			continue
+		}
+
+		resultContract := ContractNeverNil
+		for _, returnStmt := range returnList {
+			if f.isResultMayBeNil(decl.Body, returnStmt, i, sign.Results()) {
+				resultContract = ContractMayBeNil
+				break
 			}
 		}
-	})
 
-	return nodeList
+		contract.Results = append(contract.Results, &ValueContract{
+			Index:    i,
+			Name:     result.Name(),
+			Type:     types.TypeString(result.Type(), qualifier),
+			Contract: resultContract,
+		})
+	}
+
+	return contract
 }
 
-func TravelSelectorName(expr *ast.SelectorExpr, fset *token.FileSet) []string {
-	var nodeNameList []string
-	WalkSelector(expr, fset, func(node ast.Node) {
-		switch exprInner := node.(type) {
-		case *ast.SelectorExpr:
-			if exprInner != nil && exprInner.Sel != nil {
-				nodeNameList = append(nodeNameList, exprInner.Sel.Name)
+// recordParamDeref 参数 p 被引用时, p 在该位置没有生效的非 nil 记录, 则为未经校验的引用
+func (f *FuncDelChecker) recordParamDeref(expr ast.Expr, derefMap map[types.Object]bool) {
+	ident, ok := expr.(*ast.Ident)
+	if !ok {
+		return
+	}
+
+	obj, ok := f.pass.TypesInfo.ObjectOf(ident).(*types.Var)
+	if !ok || !IsPointer(obj.Type()) {
+		return
+	}
+
+	pos := f.pass.Fset.Position(ident.Pos())
+	index := f.findFirstSuitablePosIndexFromEnd(ident.Name, pos)
+	if index < 0 || !f.needCheckPointerPositionMap[ident.Name][index].IsChecked {
+		derefMap[obj] = true
+	}
+}
+
+// isCheckedParam 参数有来自 nil 校验的记录, 赋值产生的记录除外
+func (f *FuncDelChecker) isCheckedParam(name string) bool {
+	for _, record := range f.needCheckPointerPositionMap[name] {
+		if record.IsChecked && !strings.HasPrefix(record.Reason, "assigned") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isResultMayBeNil 第 index 个返回值在 return 语句处可能为 nil, 如 return nil, 未校验的变量, 函数调用结果
+func (f *FuncDelChecker) isResultMayBeNil(body *ast.BlockStmt, returnStmt *ast.ReturnStmt, index int, resultList *types.Tuple) bool {
+	var expr ast.Expr
+	switch len(returnStmt.Results) {
+	case resultList.Len():
+		expr = returnStmt.Results[index]
+	case 0: // 命名返回值
+		expr = &ast.Ident{NamePos: returnStmt.Pos(), Name: resultList.At(index).Name()}
+	default: // return f(), f 返回多个值
+		return true
+	}
+
+	for {
+		paren, ok := expr.(*ast.ParenExpr)
+		if !ok {
+			break
+		}
+		expr = paren.X
+	}
+
+	if IsNonNilValue(expr, f.pass.TypesInfo) {
+		return false
+	}
+
+	name := GetAccessPathName(expr)
+	if name == "" || IsNilIdent(expr) {
+		return true
+	}
+
+	recordIndex := f.findFirstSuitablePosIndexFromEnd(name, f.pass.Fset.Position(returnStmt.Pos()))
+	if recordIndex >= 0 {
+		return !f.needCheckPointerPositionMap[name][recordIndex].IsChecked
+	}
+
+	// 未记录的局部变量, 如 u := &User{}
+	ident, ok := expr.(*ast.Ident)
+	if !ok || recordIndex != -1 {
+		return true
+	}
+
+	obj, ok := f.pass.TypesInfo.ObjectOf(ident).(*types.Var)
+	return !ok || obj.Parent() == nil || obj.Parent() == f.pass.Pkg.Scope() || !IsAlwaysAssignedNonNil(obj, body, f.pass.TypesInfo)
+}
+
+// IsNonNilValue 一定非 nil 的值, 如 &User{}, new(User)
+func IsNonNilValue(expr ast.Expr, typeInfo *types.Info) bool {
+	switch ex := expr.(type) {
+	case *ast.UnaryExpr:
+		return ex.Op == token.AND
+
+	case *ast.CallExpr:
+		ident, ok := ex.Fun.(*ast.Ident)
+		if !ok {
+			return false
+		}
+
+		builtin, ok := typeInfo.ObjectOf(ident).(*types.Builtin)
+		return ok && builtin.Name() == "new"
+	}
+
+	return false
+}
+
+// IsAlwaysAssignedNonNil 局部变量的每次赋值都非 nil, 且没有取地址
+func IsAlwaysAssignedNonNil(obj *types.Var, body *ast.BlockStmt, typeInfo *types.Info) bool {
+	var (
+		isAssigned bool
+		isNonNil   = true
+	)
+
+	isVar := func(expr ast.Expr) bool {
+		ident, ok := expr.(*ast.Ident)
+		return ok && typeInfo.ObjectOf(ident) == obj
+	}
+
+	ast.Inspect(body, func(n ast.Node) bool {
+		switch node := n.(type) {
+		case *ast.AssignStmt:
+			for i, lhs := range node.Lhs {
+				if !isVar(lhs) {
+					continue
+				}
+
+				isAssigned = true
+				if len(node.Lhs) != len(node.Rhs) || !IsNonNilValue(node.Rhs[i], typeInfo) {
+					isNonNil = false
+				}
 			}
 
-		case *ast.Ident:
-			if exprInner != nil {
-				nodeNameList = append(nodeNameList, exprInner.Name)
-				sort.SliceStable(nodeNameList, func(i, j int) bool { // 反转数组
-					return i > j
-				})
+		case *ast.ValueSpec: // var u *User
+			for i, name := range node.Names {
+				if isVar(name) && (len(node.Names) != len(node.Values) || !IsNonNilValue(node.Values[i], typeInfo)) {
+					isNonNil = false
+				}
+			}
+
+		case *ast.RangeStmt:
+			if isVar(node.Key) || isVar(node.Value) {
+				isNonNil = false
+			}
+
+		case *ast.UnaryExpr: // &u
+			if node.Op == token.AND && isVar(node.X) {
+				isNonNil = false
 			}
 		}
+		return isNonNil
 	})
 
-	return nodeNameList
+	return isAssigned && isNonNil
 }
 
-func (f *FuncDelChecker) getPotentialNilPointerReference(
-	varName string,
-	filePath string,
-	pos *token.Position,
-	expr *ast.Ident,
-	isComeFromOutSide bool,
-) *LintError {
-	if pos == nil || len(f.needCheckPointerPositionMap) == 0 {
-		return nil
+// CountNodes 统计 AST 节点数, 用于估计函数的复杂度
+func CountNodes(node ast.Node) int {
+	count := 0
+	ast.Inspect(node, func(n ast.Node) bool {
+		if n != nil {
+			count++
+		}
+		return true
+	})
+
+	return count
+}
+
+// GetFuncDeclName 获取函数名, 方法带上接收者类型, 如 Find, (*Repo).Find
+func GetFuncDeclName(decl *ast.FuncDecl, typeInfo *types.Info) string {
+	fn, ok := typeInfo.Defs[decl.Name].(*types.Func)
+	if !ok {
+		return decl.Name.Name
 	}
 
-	refName := RemoveVarLeafNode(varName, f.originFieldMap)
-	if refName == "" {
+	recv := fn.Type().(*types.Signature).Recv()
+	if recv == nil {
+		return fn.Name()
+	}
+
+	return "(" + types.TypeString(recv.Type(), types.RelativeTo(fn.Pkg())) + ")." + fn.Name()
+}
+
+type FuncDelChecker struct {
+	pass *analysis.Pass
+
+	okVarMap                    map[string]string         // ok -> v, v, ok := m[k]
+	errVarMap                   map[string]string         // err -> u, u, err := repo.Find(id)
+	errCheckedPositionMap       map[string]token.Position // u -> if err != nil 的位置
+	errPairedPositionMap        map[string]token.Position // u -> u, err := repo.Find(id) 的位置
+	needCheckPointerPositionMap map[string][]*CheckPointerPosition
+	aliasMap                    map[string][]string // d -> [d, a], a := d
+	recordNameIndexMap          map[string][]string // needCheckPointerPositionMap 中变量名的索引, d -> [d, d.A], [i] -> [items.[i]]
+	aliasNameIndexMap           map[string][]string // aliasMap 中变量名的索引
+	changedNameList             []string            // 校验记录有变化的变量, 用于计算 if、switch 语句之后的校验
+	reportedPosMap              map[token.Pos]bool
+
+	blockEnd    token.Pos             // 当前代码块结束位置
+	isSilent    bool                  // 只检测不报告
+	resultList  *types.Tuple          // 当前函数的返回值类型, 用于检测 return 语句
+	diagnostics []analysis.Diagnostic // 待报告的提示, 函数检测完成后统一报告
+	deadline    time.Time             // -func-timeout 的截止时间
+
+	constructorFieldMap map[*types.TypeName]map[string]bool // Service -> 在 NewService 中赋值的字段
+	nilableGlobalMap    map[*types.Var]bool                 // 当前包中可能为 nil 的全局指针变量
+	configuredGuardMap  map[string]*GuardFact               // -guards 参数中的校验函数, FullName -> GuardFact
+	guardFuncMap        map[*types.Func]*GuardFact          // 当前包中推断的校验函数
+}
+
+func InitFuncDelChecker(pass *analysis.Pass) *FuncDelChecker {
+	if pass == nil {
 		return nil
 	}
 
-	//needCheckInfo, ok := needCheckPointerPositionMap[refName]
-	index := f.findFirstSuitablePosIndexFromEnd(refName, *pos)
+	return &FuncDelChecker{
+		pass:                        pass,
+		aliasMap:                    make(map[string][]string),
+		recordNameIndexMap:          make(map[string][]string),
+		aliasNameIndexMap:           make(map[string][]string),
+		okVarMap:                    make(map[string]string),
+		errVarMap:                   make(map[string]string),
+		errCheckedPositionMap:       make(map[string]token.Position),
+		errPairedPositionMap:        make(map[string]token.Position),
+		reportedPosMap:              make(map[token.Pos]bool),
+		needCheckPointerPositionMap: make(map[string][]*CheckPointerPosition),
+	}
+}
 
-	if isComeFromOutSide && index < 0 {
-		f.pass.Reportf(expr.Pos(), NPEMessageTipInfo)
-		return &LintError{
-			Message: NPEMessageTipInfo,
-			File:    filePath,
-			Line:    pos.Line,
-			Colum:   pos.Column,
-		}
+func (f *FuncDelChecker) detectNilPointerReference(
+	decl *ast.FuncDecl,
+	fset *token.FileSet,
+	lintErrorList *[]*LintError) {
+	if decl == nil || decl.Body == nil {
+		return
 	}
 
-	if index >= 0 {
-		if lintError := f.buildLintError(f.needCheckPointerPositionMap[refName][index], filePath, pos); lintError != nil {
-			f.pass.Reportf(expr.Pos(), NPEMessageTipInfo)
-			return lintError
-		}
+	if fn, ok := f.pass.TypesInfo.Defs[decl.Name].(*types.Func); ok {
+		f.resultList = fn.Type().(*types.Signature).Results()
 	}
 
-	return nil
+	for _, stmt := range decl.Body.List {
+		f.detectNPEInStatement(stmt, fset, lintErrorList)
+	}
 }
 
-func (f *FuncDelChecker) buildLintError(needCheckInfo *CheckPointerPosition, filePath string, pos *token.Position) *LintError {
-	lintErr := &LintError{
-		Message: NPEMessageTipInfo,
-		File:    filePath,
-		Line:    pos.Line,
-		Colum:   pos.Column,
+func (f *FuncDelChecker) detectNPEInStatement(stmt ast.Stmt, fset *token.FileSet, npeLintErrorListPtr *[]*LintError) {
+	f.checkDeadline()
+	switch s := stmt.(type) {
+	case *ast.SwitchStmt:
+		if s.Tag != nil {
+			f.detectTypeAssertExpr(s.Tag, fset, npeLintErrorListPtr)
+		}
+
+	case *ast.RangeStmt:
+		f.detectTypeAssertExpr(s.X, fset, npeLintErrorListPtr)
+
+	case *ast.AssignStmt, *ast.ExprStmt, *ast.DeferStmt, *ast.GoStmt, *ast.IncDecStmt, *ast.ReturnStmt:
+		f.detectTypeAssertExpr(s, fset, npeLintErrorListPtr)
 	}
 
-	if needCheckInfo == nil {
-		return lintErr
+	switch s := stmt.(type) {
+	case *ast.IfStmt:
+		f.detectIfStatementBlock(s, fset, npeLintErrorListPtr)
+
+	case *ast.AssignStmt:
+		f.detectAssignmentStatementBlock(s, fset, npeLintErrorListPtr)
+
+	case *ast.ExprStmt:
+		f.detectExprStatementBlock(s, fset, npeLintErrorListPtr)
+
+	case *ast.RangeStmt:
+		f.detectRangeStatementBlock(s, fset, npeLintErrorListPtr)
+
+	case *ast.SwitchStmt:
+		f.detectSwitchStatementBlock(s, fset, npeLintErrorListPtr)
+
+	case *ast.DeferStmt:
+		f.detectCallExprBlock(s.Call, fset, npeLintErrorListPtr)
+
+	case *ast.GoStmt:
+		f.detectCallExprBlock(s.Call, fset, npeLintErrorListPtr)
+
+	case *ast.IncDecStmt:
+		f.detectNilMapWrite(s.X, fset, npeLintErrorListPtr)
+
+	case *ast.TypeSwitchStmt:
+		f.detectTypeSwitchStatementBlock(s, fset, npeLintErrorListPtr)
+
+	case *ast.ReturnStmt:
+		f.detectReturnStatementBlock(s, npeLintErrorListPtr)
+
+	case *ast.DeclStmt:
+		f.detectDeclStatementBlock(s, npeLintErrorListPtr)
 	}
+}
 
-	if needCheckInfo.IsChecked == false {
-		return lintErr
+// detectReturnStatementBlock 检测返回值, 如 func f() error { var e *MyErr; return e }
+func (f *FuncDelChecker) detectReturnStatementBlock(s *ast.ReturnStmt, npeLintErrorListPtr *[]*LintError) {
+	if f.resultList == nil || len(s.Results) != f.resultList.Len() {
+		return
 	}
 
-	if needCheckInfo.Line > pos.Line || (needCheckInfo.Line == pos.Line && needCheckInfo.Colum > pos.Column) {
-		return lintErr
+	for i, expr := range s.Results {
+		f.detectTypedNilInInterface(expr, f.resultList.At(i).Type(), npeLintErrorListPtr)
 	}
-	return nil
 }
 
-func Run(pass *analysis.Pass) (interface{}, error) {
-	var (
-		fset          = pass.Fset
-		lintErrorList = make([]*LintError, 0)
-	)
+// detectDeclStatementBlock 检测变量声明, 如 var err error = e
+func (f *FuncDelChecker) detectDeclStatementBlock(s *ast.DeclStmt, npeLintErrorListPtr *[]*LintError) {
+	genDecl, ok := s.Decl.(*ast.GenDecl)
+	if !ok || genDecl.Tok != token.VAR {
+		return
+	}
 
-	for _, file := range pass.Files {
-		var fileName = fset.PositionFor(file.Pos(), false).Filename
-		if strings.HasSuffix(fileName, "_test.go") {
+	for _, spec := range genDecl.Specs {
+		valueSpec, ok := spec.(*ast.ValueSpec)
+		if !ok || len(valueSpec.Names) != len(valueSpec.Values) {
 			continue
 		}
 
-		for _, decl := range file.Decls {
-			switch decl := decl.(type) {
-			case *ast.FuncDecl:
-				checker := InitFuncDelChecker(pass)
-				checker.preRecordNilPointerFromOutside(decl, &lintErrorList)
-				checker.detectNilPointerReference(decl, fset, &lintErrorList)
-			}
+		for i, expr := range valueSpec.Values {
+			f.detectTypedNilInInterface(expr, f.pass.TypesInfo.TypeOf(valueSpec.Names[i]), npeLintErrorListPtr)
 		}
 	}
-	// fmt.Println(lintErrorList)
-	return nil, nil
 }
 
-type FuncDelChecker struct {
-	pass *analysis.Pass
+// detectTypedNilInInterface 检测将可能为 nil 的指针赋值给接口, 得到的接口不为 nil, 如 var err error = (*MyErr)(nil), err != nil 为 true
+func (f *FuncDelChecker) detectTypedNilInInterface(expr ast.Expr, interfaceType types.Type, npeLintErrorListPtr *[]*LintError) {
+	if interfaceType == nil || !types.IsInterface(interfaceType) {
+		return
+	}
+
+	if _, ok := interfaceType.(*types.TypeParam); ok {
+		return
+	}
+
+	if !IsPointer(f.pass.TypesInfo.TypeOf(expr)) || !f.isLocalNilValue(expr) {
+		return
+	}
+
+	pos := f.pass.Fset.Position(expr.Pos())
+	f.report(expr.Pos(), NPETypedNilTipInfo)
+	*npeLintErrorListPtr = append(*npeLintErrorListPtr, &LintError{
+		Message: NPETypedNilTipInfo,
+		File:    pos.Filename,
+		Line:    pos.Line,
+		Colum:   pos.Column,
+	})
+}
+
+// isLocalNilValue 在函数内可确定可能为 nil 的值: 未赋值的声明, 赋值为 nil, 函数调用结果, 容器元素;
+// 未校验的参数、字段等来自外部的值不视为 nil, 如 x.expr = call, return t.transport
+func (f *FuncDelChecker) isLocalNilValue(expr ast.Expr) bool {
+	typeInfo := f.pass.TypesInfo
+	switch ex := expr.(type) {
+	case *ast.ParenExpr:
+		return f.isLocalNilValue(ex.X)
+
+	case *ast.CallExpr:
+		if tv, ok := typeInfo.Types[ex.Fun]; ok && tv.IsType() && len(ex.Args) == 1 { // (*MyErr)(nil), (*MyErr)(e)
+			return IsNilIdent(ex.Args[0]) || f.isLocalNilValue(ex.Args[0])
+		}
+		return !f.isNonNilExpr(ex, typeInfo)
+
+	case *ast.IndexExpr: // 包级别的查找表除外, 如 Typ[Invalid]
+		return !IsPackageTableElem(ex, f.pass.Pkg, typeInfo)
+
+	case *ast.UnaryExpr: // <-ch
+		return ex.Op == token.ARROW
+
+	case *ast.Ident, *ast.SelectorExpr:
+		name := GetAccessPathName(ex)
+		if name == "" {
+			return false
+		}
+
+		recordList := f.needCheckPointerPositionMap[name]
+		index := f.findFirstSuitablePosIndexFromEnd(name, f.pass.Fset.Position(ex.Pos()))
+		if index < 0 || recordList[index].IsChecked {
+			return false
+		}
+
+		switch recordList[index].Reason {
+		case ReasonDeclared, ReasonAssignNil, ReasonCallResult, ReasonElemValue, ReasonRangeValue:
+			return true
+		}
+	}
 
-	originFieldMap              map[string]string
-	needCheckPointerPositionMap map[string][]*CheckPointerPosition
+	return false
 }
 
-func InitFuncDelChecker(pass *analysis.Pass) *FuncDelChecker {
-	if pass == nil {
-		return nil
+func (f *FuncDelChecker) detectTypeSwitchStatementBlock(s *ast.TypeSwitchStmt, fset *token.FileSet, npeLintErrorListPtr *[]*LintError) {
+	if s == nil || s.Body == nil {
+		return
 	}
 
-	return &FuncDelChecker{
-		pass:                        pass,
-		originFieldMap:              make(map[string]string),
-		needCheckPointerPositionMap: make(map[string][]*CheckPointerPosition),
+	if s.Init != nil {
+		f.detectNPEInStatement(s.Init, fset, npeLintErrorListPtr)
 	}
-}
 
-func (f *FuncDelChecker) detectNilPointerReference(
-	decl *ast.FuncDecl,
-	fset *token.FileSet,
-	lintErrorList *[]*LintError) {
-	for _, stmt := range decl.Body.List {
-		f.detectNPEInStatement(stmt, fset, lintErrorList)
+	for _, b := range s.Body.List {
+		if caseClause, ok := b.(*ast.CaseClause); ok {
+			for _, stmt := range caseClause.Body {
+				f.detectNPEInStatement(stmt, fset, npeLintErrorListPtr)
+			}
+		}
 	}
 }
 
-func (f *FuncDelChecker) detectNPEInStatement(stmt ast.Stmt, fset *token.FileSet, npeLintErrorListPtr *[]*LintError) {
-	switch s := stmt.(type) {
-	case *ast.IfStmt:
-		f.detectIfStatementBlock(s, fset, npeLintErrorListPtr)
+// detectTypeAssertExpr 检测单值类型断言 x.(*T), 动态类型不匹配时 panic, 不包括 comma-ok 形式以及函数字面量内的断言
+func (f *FuncDelChecker) detectTypeAssertExpr(node ast.Node, fset *token.FileSet, npeLintErrorListPtr *[]*LintError) {
+	if !typeAssertion {
+		return
+	}
 
-	case *ast.AssignStmt:
-		f.detectAssignmentStatementBlock(s, fset, npeLintErrorListPtr)
+	commaOkMap := make(map[ast.Expr]bool)
+	ast.Inspect(node, func(n ast.Node) bool {
+		switch ex := n.(type) {
+		case *ast.FuncLit:
+			return false
 
-	case *ast.ExprStmt:
-		f.detectExprStatementBlock(s, fset, npeLintErrorListPtr)
+		case *ast.AssignStmt: // v, ok := x.(*T)
+			if len(ex.Lhs) == 2 && len(ex.Rhs) == 1 {
+				commaOkMap[ex.Rhs[0]] = true
+			}
 
-	case *ast.RangeStmt:
-		f.detectRangeStatementBlock(s, fset, npeLintErrorListPtr)
+		case *ast.TypeAssertExpr:
+			if ex.Type != nil && !commaOkMap[ex] {
+				f.detectUncheckedTypeAssert(ex, fset, npeLintErrorListPtr)
+			}
+		}
 
-	case *ast.SwitchStmt:
-		f.detectSwitchStatementBlock(s, fset, npeLintErrorListPtr)
+		return true
+	})
+}
+
+func (f *FuncDelChecker) detectUncheckedTypeAssert(ex *ast.TypeAssertExpr, fset *token.FileSet, npeLintErrorListPtr *[]*LintError) {
+	pos := fset.Position(ex.Pos())
+	if name := GetAccessPathName(ex); name != "" {
+		if index := f.findFirstSuitablePosIndexFromEnd(name, pos); index >= 0 {
+			if f.buildLintError(f.needCheckPointerPositionMap[name][index], pos.Filename, &pos) == nil {
+				return
+			}
+		}
 	}
+
+	f.report(ex.Lparen, NPETypeAssertTipInfo)
+	*npeLintErrorListPtr = append(*npeLintErrorListPtr, &LintError{
+		Message: NPETypeAssertTipInfo,
+		File:    pos.Filename,
+		Line:    pos.Line,
+		Colum:   pos.Column,
+	})
 }
 
 func (f *FuncDelChecker) detectSwitchStatementBlock(s *ast.SwitchStmt, fset *token.FileSet, npeLintErrorListPtr *[]*LintError) {
@@ -929,12 +3545,17 @@ func (f *FuncDelChecker) detectSwitchStatementBlock(s *ast.SwitchStmt, fset *tok
 		return
 	}
 
+	if s.Init != nil {
+		f.detectNPEInStatement(s.Init, fset, npeLintErrorListPtr)
+	}
+
 	for _, b := range s.Body.List {
 		switch bStmt := b.(type) {
 		case *ast.CaseClause:
 			for _, expr := range bStmt.Body {
 				switch expr := expr.(type) {
-				case *ast.IfStmt, *ast.AssignStmt, *ast.ExprStmt, *ast.RangeStmt, *ast.SwitchStmt:
+				case *ast.IfStmt, *ast.AssignStmt, *ast.ExprStmt, *ast.RangeStmt, *ast.SwitchStmt, *ast.DeferStmt, *ast.GoStmt, *ast.IncDecStmt,
+					*ast.ReturnStmt, *ast.TypeSwitchStmt, *ast.DeclStmt:
 					f.detectNPEInStatement(expr, fset, npeLintErrorListPtr)
 				}
 			}
@@ -953,7 +3574,8 @@ func (f *FuncDelChecker) detectRangeStatementBlock(s *ast.RangeStmt, fset *token
 
 	for _, b := range s.Body.List {
 		switch bStmt := b.(type) {
-		case *ast.IfStmt, *ast.AssignStmt, *ast.ExprStmt, *ast.RangeStmt, *ast.SwitchStmt:
+		case *ast.IfStmt, *ast.AssignStmt, *ast.ExprStmt, *ast.RangeStmt, *ast.SwitchStmt, *ast.DeferStmt, *ast.GoStmt, *ast.IncDecStmt,
+			*ast.ReturnStmt, *ast.TypeSwitchStmt, *ast.DeclStmt:
 			f.detectNPEInStatement(bStmt, fset, npeLintErrorListPtr)
 		}
 	}
@@ -962,38 +3584,130 @@ func (f *FuncDelChecker) detectRangeStatementBlock(s *ast.RangeStmt, fset *token
 func (f *FuncDelChecker) detectExprStatementBlock(s *ast.ExprStmt, fset *token.FileSet, lintErrorListPtr *[]*LintError) {
 	switch callExpr := s.X.(type) {
 	case *ast.CallExpr:
-		if selectExpr, ok := callExpr.Fun.(*ast.SelectorExpr); ok {
-			lintErrors := f.detectSelectorReferenceWithFunc(selectExpr, fset)
+		f.detectCallExprBlock(callExpr, fset, lintErrorListPtr)
+	}
+}
+
+// detectCallExprBlock 检测函数调用及其参数, 包括 defer、go 语句中的调用, 如 defer resp.Body.Close()
+func (f *FuncDelChecker) detectCallExprBlock(callExpr *ast.CallExpr, fset *token.FileSet, lintErrorListPtr *[]*LintError) {
+	if callExpr == nil {
+		return
+	}
+
+	f.detectFuncValueCall(callExpr, fset, lintErrorListPtr)
+	switch fun := callExpr.Fun.(type) {
+	case *ast.SelectorExpr:
+		lintErrors := f.detectSelectorReferenceWithFunc(fun, fset)
+		if len(lintErrors) > 0 {
+			*lintErrorListPtr = append(*lintErrorListPtr, lintErrors...)
+		}
+
+	case *ast.IndexExpr: // handlers[name](), d.Handlers[name]()
+		f.detectExprReference(fun, fset, lintErrorListPtr)
+
+	case *ast.FuncLit: // defer func() { resp.Body.Close() }()
+		if fun.Body != nil {
+			prevResultList := f.resultList
+			if sign, ok := f.pass.TypesInfo.TypeOf(fun).(*types.Signature); ok {
+				f.resultList = sign.Results()
+			}
+
+			for _, stmt := range fun.Body.List {
+				f.detectNPEInStatement(stmt, fset, lintErrorListPtr)
+			}
+			f.resultList = prevResultList
+		}
+	}
+
+	for _, expr := range callExpr.Args {
+		switch expr := expr.(type) {
+		case *ast.SelectorExpr:
+			lintErrors := f.detectSelectorReferenceWithFunc(expr, fset)
 			if len(lintErrors) > 0 {
 				*lintErrorListPtr = append(*lintErrorListPtr, lintErrors...)
 			}
-		}
 
-		for _, expr := range callExpr.Args {
-			switch expr := expr.(type) {
-			case *ast.SelectorExpr:
-				lintErrors := f.detectSelectorReferenceWithFunc(expr, fset)
+		case *ast.CallExpr:
+			f.detectFuncValueCall(expr, fset, lintErrorListPtr)
+			if selectExpr, ok := expr.Fun.(*ast.SelectorExpr); ok {
+				lintErrors := f.detectSelectorReferenceWithFunc(selectExpr, fset)
 				if len(lintErrors) > 0 {
 					*lintErrorListPtr = append(*lintErrorListPtr, lintErrors...)
 				}
-
-			case *ast.CallExpr:
-				if selectExpr, ok := expr.Fun.(*ast.SelectorExpr); ok {
-					lintErrors := f.detectSelectorReferenceWithFunc(selectExpr, fset)
-					if len(lintErrors) > 0 {
-						*lintErrorListPtr = append(*lintErrorListPtr, lintErrors...)
-					}
-				}
 			}
 		}
+	}
+}
+
+// detectFuncValueCall 检测函数类型变量的调用, 调用 nil 函数同样会 panic, 如 cb(), opts.OnError(err), handlers[name]()
+func (f *FuncDelChecker) detectFuncValueCall(callExpr *ast.CallExpr, fset *token.FileSet, lintErrorListPtr *[]*LintError) {
+	fun := callExpr.Fun
+	if !IsFuncValue(fun, f.pass.TypesInfo) {
+		return
+	}
+
+	f.detectUncheckedValue(fun, NPEFuncCallTipInfo, fset, lintErrorListPtr)
+}
+
+// detectNilMapWrite 检测写入可能为 nil 的 map, 如 m[k] = v, cfg.Labels[k] = v, m[k]++
+func (f *FuncDelChecker) detectNilMapWrite(expr ast.Expr, fset *token.FileSet, lintErrorListPtr *[]*LintError) {
+	indexExpr, ok := expr.(*ast.IndexExpr)
+	if !ok || !IsMap(f.pass.TypesInfo.TypeOf(indexExpr.X)) {
+		return
+	}
+
+	f.detectExprReference(indexExpr.X, fset, lintErrorListPtr)
+	f.detectUncheckedValue(indexExpr.X, NPENilMapWriteTipInfo, fset, lintErrorListPtr)
+}
+
+// detectUncheckedValue 检测外部来源且在引用处未校验的变量, 如函数变量、map 变量
+func (f *FuncDelChecker) detectUncheckedValue(expr ast.Expr, message string, fset *token.FileSet, lintErrorListPtr *[]*LintError) {
+	name := GetAccessPathName(expr)
+	if !f.isRootComeFromOutside(name) {
+		return
+	}
+
+	pos := fset.Position(expr.Pos())
+	if index := f.findFirstSuitablePosIndexFromEnd(name, pos); index >= 0 {
+		if f.buildLintError(f.needCheckPointerPositionMap[name][index], pos.Filename, &pos) == nil {
+			return
+		}
+	}
+
+	reportPos := expr.Pos()
+	switch ex := expr.(type) {
+	case *ast.SelectorExpr:
+		reportPos = ex.Sel.Pos()
 
+	case *ast.IndexExpr:
+		reportPos = ex.Lbrack
 	}
+
+	f.report(reportPos, message)
+	*lintErrorListPtr = append(*lintErrorListPtr, &LintError{
+		Message: message,
+		File:    pos.Filename,
+		Line:    pos.Line,
+		Colum:   pos.Column,
+	})
 }
 
 func (f *FuncDelChecker) detectAssignmentStatementBlock(
 	s *ast.AssignStmt,
 	fset *token.FileSet,
 	npeLintErrorListPtr *[]*LintError) {
+	if s.Tok != token.DEFINE {
+		for _, expr := range s.Lhs {
+			f.detectNilMapWrite(expr, fset, npeLintErrorListPtr)
+		}
+	}
+
+	if s.Tok == token.ASSIGN && len(s.Lhs) == len(s.Rhs) { // err = e
+		for i, expr := range s.Rhs {
+			f.detectTypedNilInInterface(expr, f.pass.TypesInfo.TypeOf(s.Lhs[i]), npeLintErrorListPtr)
+		}
+	}
+
 	for _, expr := range s.Rhs {
 		switch EX := expr.(type) {
 		case *ast.SelectorExpr:
@@ -1003,35 +3717,38 @@ func (f *FuncDelChecker) detectAssignmentStatementBlock(
 			}
 
 		case *ast.CallExpr:
+			f.detectFuncValueCall(EX, fset, npeLintErrorListPtr)
 			if selectExpr, ok := EX.Fun.(*ast.SelectorExpr); ok {
 				lintErrors := f.detectSelectorReferenceWithFunc(selectExpr, fset)
 				if len(lintErrors) > 0 {
 					*npeLintErrorListPtr = append(*npeLintErrorListPtr, lintErrors...)
 				}
 			}
+
+		case *ast.BinaryExpr: // ok := d != nil && d.A != nil
+			f.detectExprReference(EX, fset, npeLintErrorListPtr)
 		}
 	}
 }
 
 func (f *FuncDelChecker) detectIfStatementBlock(s *ast.IfStmt, fset *token.FileSet, lintErrorListPtr *[]*LintError) {
+	if s.Init != nil {
+		f.detectNPEInStatement(s.Init, fset, lintErrorListPtr)
+	}
+
+	f.detectTypeAssertExpr(s.Cond, fset, lintErrorListPtr)
 	for _, stmt := range s.Body.List {
-		switch expr := stmt.(type) {
-		case *ast.ExprStmt:
-			x := expr.X
-			switch x := x.(type) {
-			case *ast.CallExpr:
-				for _, arg := range x.Args {
-					switch EX := arg.(type) {
-					case *ast.SelectorExpr:
-						lintErrors := f.detectSelectorReferenceWithFunc(EX, fset)
-						*lintErrorListPtr = append(*lintErrorListPtr, lintErrors...)
-					}
-				}
-			}
+		f.detectNPEInStatement(stmt, fset, lintErrorListPtr)
+	}
 
-		case *ast.IfStmt, *ast.RangeStmt, *ast.SwitchStmt, *ast.AssignStmt:
-			f.detectNPEInStatement(expr, fset, lintErrorListPtr)
+	switch elseStmt := s.Else.(type) {
+	case *ast.BlockStmt:
+		for _, stmt := range elseStmt.List {
+			f.detectNPEInStatement(stmt, fset, lintErrorListPtr)
 		}
+
+	case *ast.IfStmt:
+		f.detectIfStatementBlock(elseStmt, fset, lintErrorListPtr)
 	}
 }
 
@@ -1055,6 +3772,27 @@ func (f *FuncDelChecker) detectSelectorReferenceWithFunc(
 					IsReturnSingleFunc: isReturnSingleFunc})
 			}
 
+		case *ast.IndexExpr:
+			nodeType := NodeTypeDefaultSinglePtr
+			if !IsPointer(f.pass.TypesInfo.TypeOf(exprInner)) {
+				nodeType = NodeTypeNonSinglePtr
+			}
+			nodeList = append(nodeList, &SelectNode{
+				Name:   GetIndexNodeName(exprInner.Index),
+				CurPos: exprInner.Lbrack,
+				Type:   nodeType})
+
+		case *ast.TypeAssertExpr:
+			nodeType := NodeTypeDefaultSinglePtr
+			if !IsPointer(f.pass.TypesInfo.TypeOf(exprInner)) {
+				nodeType = NodeTypeNonSinglePtr
+			}
+			nodeList = append(nodeList, &SelectNode{
+				Name:         GetTypeAssertNodeName(exprInner.Type),
+				CurPos:       exprInner.Type.Pos(),
+				Type:         nodeType,
+				IsTypeAssert: true})
+
 		case *ast.CallExpr:
 			switch exFunc := exprInner.Fun.(type) {
 			case *ast.SelectorExpr:
@@ -1084,9 +3822,7 @@ func (f *FuncDelChecker) detectSelectorReferenceWithFunc(
 					CurIdent:           exprInner,
 					Type:               nodeType,
 					IsReturnSingleFunc: isReturnSingleFunc})
-				sort.SliceStable(nodeList, func(i, j int) bool {
-					return i > j
-				})
+				reverseSlice(nodeList)
 
 				var pos token.Position
 				GetIdentPosition(&pos, exprInner, fset)
@@ -1107,7 +3843,10 @@ func (f *FuncDelChecker) hasSequenceDetectNode(
 	// funcIndex int, //o.GetUserInfo().GetMembership() -- funcIndex: 1
 	isRootFromOutside bool,
 ) []*LintError {
-	var result = make([]*LintError, 0)
+	var (
+		result    = make([]*LintError, 0)
+		reportPos = expr.Pos()
+	)
 	if len(nodeList) <= 1 {
 		return nil
 	}
@@ -1124,10 +3863,14 @@ func (f *FuncDelChecker) hasSequenceDetectNode(
 
 		fieldName := buildFieldNameFromNodes(nodeList)
 		if len(nodeList) >= 2 && nodeList[len(nodeList)-2].CurIdent != nil {
-			expr = nodeList[len(nodeList)-2].CurIdent
+			reportPos = nodeList[len(nodeList)-2].CurIdent.Pos()
+		} else if len(nodeList) >= 2 && nodeList[len(nodeList)-2].CurPos.IsValid() {
+			reportPos = nodeList[len(nodeList)-2].CurPos
 		}
 
-		lintError := f.getPotentialNilPointerReference(fieldName, pos.Filename, &pos, expr, isRootFromOutside)
+		// 类型断言的结果不论根节点来源都可能为 nil, 如 ctx.Value(key).(*User).ID
+		isComeFromOutside := isRootFromOutside || hasTypeAssertNode(nodeList[:len(nodeList)-1]) || f.isPtrFuncCallRoot(nodeList)
+		lintError := f.getPotentialNilPointerReference(fieldName, pos.Filename, &pos, reportPos, isComeFromOutside)
 		if lintError != nil {
 			result = append(result, lintError)
 		}
@@ -1137,6 +3880,252 @@ func (f *FuncDelChecker) hasSequenceDetectNode(
 	return result
 }
 
+// isPtrFuncCallRoot 根节点为返回单个指针的函数调用, 如 First[User](xs).Name, FindUser(id).Name; New* 构造函数除外
+func (f *FuncDelChecker) isPtrFuncCallRoot(nodeList []*SelectNode) bool {
+	root := nodeList[0]
+	if !root.IsReturnSingleFunc || root.Type != NodeTypeDefaultSinglePtr || root.CurIdent == nil || IsFuncPtrRespNeedSkip(root.Name) {
+		return false
+	}
+
+	_, ok := f.pass.TypesInfo.ObjectOf(root.CurIdent).(*types.Func)
+	return ok
+}
+
+func hasTypeAssertNode(nodeList []*SelectNode) bool {
+	for _, node := range nodeList {
+		if node.IsTypeAssert {
+			return true
+		}
+	}
+
+	return false
+}
+
+func IsErrorType(typ types.Type) bool {
+	return typ != nil && types.Identical(typ, types.Universe.Lookup("error").Type())
+}
+
+// IsTerminatingBlock 判断代码块是否以 return、panic、continue、break、goto、os.Exit 等结束
+func IsTerminatingBlock(block *ast.BlockStmt, typeInfo *types.Info) bool {
+	if block == nil || len(block.List) == 0 {
+		return false
+	}
+
+	return IsTerminatingStmt(block.List[len(block.List)-1], typeInfo)
+}
+
+func IsTerminatingStmt(stmt ast.Stmt, typeInfo *types.Info) bool {
+	switch s := stmt.(type) {
+	case *ast.ReturnStmt:
+		return true
+
+	case *ast.BranchStmt:
+		return s.Tok != token.FALLTHROUGH
+
+	case *ast.BlockStmt:
+		return IsTerminatingBlock(s, typeInfo)
+
+	case *ast.IfStmt:
+		return s.Else != nil && IsTerminatingBlock(s.Body, typeInfo) && IsTerminatingStmt(s.Else, typeInfo)
+
+	case *ast.ExprStmt:
+		if call, ok := s.X.(*ast.CallExpr); ok {
+			return IsTerminatingCall(call, typeInfo)
+		}
+	}
+
+	return false
+}
+
+// IsTerminatingCaseClause case 分支是否以 return、panic 等结束, 不带 label 的 break 只跳出 switch
+func IsTerminatingCaseClause(caseClause *ast.CaseClause, typeInfo *types.Info) bool {
+	if len(caseClause.Body) == 0 {
+		return false
+	}
+
+	lastStmt := caseClause.Body[len(caseClause.Body)-1]
+	if branchStmt, ok := lastStmt.(*ast.BranchStmt); ok && branchStmt.Tok == token.BREAK && branchStmt.Label == nil {
+		return false
+	}
+
+	return IsTerminatingStmt(lastStmt, typeInfo)
+}
+
+func IsFallthroughClause(caseClause *ast.CaseClause) bool {
+	if len(caseClause.Body) == 0 {
+		return false
+	}
+
+	branchStmt, ok := caseClause.Body[len(caseClause.Body)-1].(*ast.BranchStmt)
+	return ok && branchStmt.Tok == token.FALLTHROUGH
+}
+
+// IsTerminatingCall panic(...), os.Exit(...), log.Fatal(...), runtime.Goexit() 等
+func IsTerminatingCall(call *ast.CallExpr, typeInfo *types.Info) bool {
+	switch fn := call.Fun.(type) {
+	case *ast.Ident:
+		return fn.Name == "panic"
+
+	case *ast.SelectorExpr:
+		if IsTestingTerminatingMethod(fn, typeInfo) { // t.Fatal(...), s.T.FailNow()
+			return true
+		}
+
+		pkgIdent, ok := fn.X.(*ast.Ident)
+		if !ok {
+			return false
+		}
+
+		// 按导入路径判断, 名为 log 的局部变量或重命名导入的同名包不算
+		pkgName, ok := typeInfo.Uses[pkgIdent].(*types.PkgName)
+		if !ok {
+			return false
+		}
+
+		switch pkgName.Imported().Path() {
+		case "os":
+			return fn.Sel.Name == "Exit"
+		case "log":
+			return strings.HasPrefix(fn.Sel.Name, "Fatal") || strings.HasPrefix(fn.Sel.Name, "Panic")
+		case "runtime":
+			return fn.Sel.Name == "Goexit"
+		}
+	}
+
+	return false
+}
+
+// IsTestingTerminatingMethod *testing.T, *testing.B, *testing.F, testing.TB 的 Fatal, FailNow, Skip 等方法, 调用后测试函数不再继续执行
+func IsTestingTerminatingMethod(fn *ast.SelectorExpr, typeInfo *types.Info) bool {
+	switch fn.Sel.Name {
+	case "Fatal", "Fatalf", "FailNow", "Skip", "Skipf", "SkipNow":
+	default:
+		return false
+	}
+
+	// 方法定义在 testing 包的 T, B, F 共用的 common 或 TB 接口上
+	method, ok := typeInfo.Uses[fn.Sel].(*types.Func)
+	return ok && method.Pkg() != nil && method.Pkg().Path() == "testing"
+}
+
+// IsTestingParam *testing.T, *testing.B, *testing.F, *testing.M 由测试框架传入, 不会为 nil
+func IsTestingParam(typ types.Type) bool {
+	ptr, ok := typ.(*types.Pointer)
+	if !ok {
+		return false
+	}
+
+	named, ok := ptr.Elem().(*types.Named)
+	if !ok || named.Obj().Pkg() == nil || named.Obj().Pkg().Path() != "testing" {
+		return false
+	}
+
+	switch named.Obj().Name() {
+	case "T", "B", "F", "M":
+		return true
+	}
+
+	return false
+}
+
+// IsTestifyPackage 判断是否调用 testify 中 pkgName 包的函数或方法, 如 require.NotNil(t, d), r.NotNil(d)
+func IsTestifyPackage(call *ast.CallExpr, typeInfo *types.Info, pkgName string) bool {
+	selectorExpr, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok {
+		return false
+	}
+
+	fn, ok := typeInfo.Uses[selectorExpr.Sel].(*types.Func)
+	if !ok || fn.Pkg() == nil {
+		return false
+	}
+
+	return fn.Pkg().Path() == "github.com/stretchr/testify/"+pkgName
+}
+
+// GetTestifyGuardCond 将 testify 的断言转换为等价的条件, 断言成功时条件为 true
+//
+//	assert.NotNil(t, d)    -> d != nil
+//	assert.NoError(t, err) -> err == nil
+//	r.NotNil(d)            -> d != nil, r := require.New(t)
+func GetTestifyGuardCond(call *ast.CallExpr, typeInfo *types.Info) ast.Expr {
+	if !IsTestifyPackage(call, typeInfo, "assert") && !IsTestifyPackage(call, typeInfo, "require") {
+		return nil
+	}
+
+	var (
+		selectorExpr = call.Fun.(*ast.SelectorExpr)
+		argIndex     = 1
+	)
+
+	if _, ok := typeInfo.Selections[selectorExpr]; ok { // *Assertions 的方法, 没有 t 参数
+		argIndex = 0
+	}
+
+	if len(call.Args) <= argIndex {
+		return nil
+	}
+
+	var op token.Token
+	switch strings.TrimSuffix(selectorExpr.Sel.Name, "f") {
+	case "NotNil":
+		op = token.NEQ
+	case "NoError":
+		op = token.EQL
+	default:
+		return nil
+	}
+
+	return &ast.BinaryExpr{X: call.Args[argIndex], Op: op, Y: ast.NewIdent("nil")}
+}
+
+var generatedCodeRegexp = regexp.MustCompile(`^// Code generated .* DO NOT EDIT\.$`)
+
+// IsGeneratedFile 判断是否为生成的代码, 如 *.pb.go, mockgen, stringer, 在 package 之前有 // Code generated ... DO NOT EDIT.
+func IsGeneratedFile(file *ast.File) bool {
+	for _, group := range file.Comments {
+		if group.Pos() > file.Package {
+			break
+		}
+
+		for _, comment := range group.List {
+			if generatedCodeRegexp.MatchString(comment.Text) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// IsExcludedPath 判断文件路径是否匹配 -exclude 中的 glob, 可匹配路径中连续的任意几段
+//
+//	vendor         匹配 /src/app/vendor/github.com/x/y.go
+//	*.pb.go        匹配 /src/app/api/user.pb.go
+//	internal/mocks 匹配 /src/app/internal/mocks/store.go
+func IsExcludedPath(fileName, patterns string) bool {
+	if patterns == "" {
+		return false
+	}
+
+	segments := strings.Split(filepath.ToSlash(fileName), "/")
+	for _, pattern := range strings.Split(patterns, ",") {
+		pattern = strings.Trim(strings.TrimSpace(pattern), "/")
+		if pattern == "" {
+			continue
+		}
+
+		count := strings.Count(pattern, "/") + 1
+		for i := 0; i+count <= len(segments); i++ {
+			if matched, _ := path.Match(pattern, strings.Join(segments[i:i+count], "/")); matched {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
 func IsFuncPtrRespNeedSkip(name string) bool {
 	if strings.HasPrefix(name, "new") || strings.HasPrefix(name, "New") {
 		return true
@@ -1146,7 +4135,7 @@ func IsFuncPtrRespNeedSkip(name string) bool {
 }
 
 func GetFuncSignature(ex *ast.CallExpr, typeInfo *types.Info) *types.Signature {
-	if ex == nil {
+	if ex == nil || typeInfo == nil {
 		return nil
 	}
 
@@ -1156,25 +4145,27 @@ func GetFuncSignature(ex *ast.CallExpr, typeInfo *types.Info) *types.Signature {
 	)
 
 	fn := ex.Fun
+	if _, isFunc := typeInfo.TypeOf(UnwrapFuncInstance(fn)).(*types.Signature); isFunc {
+		fn = UnwrapFuncInstance(fn) // 泛型函数实例化, 如 First[User](xs)
+	}
+
 	switch fn := fn.(type) {
 	case *ast.Ident:
-		obj := typeInfo.ObjectOf(fn)
 		if fn != nil && IsFuncPtrRespNeedSkip(fn.Name) {
 			return nil
 		}
 
-		sig, ok = obj.Type().(*types.Signature)
+		sig, ok = GetInstanceType(fn, typeInfo).(*types.Signature)
 		if !ok {
 			return nil
 		}
 
 	case *ast.SelectorExpr:
-		obj := typeInfo.ObjectOf(fn.Sel)
 		if fn.Sel != nil && IsFuncPtrRespNeedSkip(fn.Sel.Name) {
 			return nil
 		}
 
-		sig, ok = obj.Type().(*types.Signature)
+		sig, ok = GetInstanceType(fn.Sel, typeInfo).(*types.Signature)
 		if !ok {
 			return nil
 		}
@@ -1182,3 +4173,16 @@ func GetFuncSignature(ex *ast.CallExpr, typeInfo *types.Info) *types.Signature {
 
 	return sig
 }
+
+// UnwrapFuncInstance 去掉泛型函数的类型实参, 如 First[User] 为 First, pkg.Map[K, V] 为 pkg.Map
+func UnwrapFuncInstance(expr ast.Expr) ast.Expr {
+	switch ex := expr.(type) {
+	case *ast.IndexExpr:
+		return ex.X
+
+	case *ast.IndexListExpr:
+		return ex.X
+	}
+
+	return expr
+}
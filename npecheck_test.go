@@ -1,8 +1,14 @@
 package go_npecheck
 
 import (
+	"bytes"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
 	"testing"
 
+	"golang.org/x/tools/go/analysis"
 	"golang.org/x/tools/go/analysis/analysistest"
 )
 
@@ -10,3 +16,156 @@ func Test(t *testing.T) {
 	testdata := analysistest.TestData()
 	analysistest.Run(t, testdata, Analyzer, "a")
 }
+
+func TestConservativeFields(t *testing.T) {
+	testdata := analysistest.TestData()
+	_ = Analyzer.Flags.Set("conservative-fields", "true")
+	defer func() {
+		_ = Analyzer.Flags.Set("conservative-fields", "false")
+	}()
+
+	analysistest.Run(t, testdata, Analyzer, "conservative")
+}
+
+func TestNilInterfaces(t *testing.T) {
+	testdata := analysistest.TestData()
+	_ = Analyzer.Flags.Set("nil-interfaces", "true")
+	defer func() {
+		_ = Analyzer.Flags.Set("nil-interfaces", "false")
+	}()
+
+	analysistest.Run(t, testdata, Analyzer, "iface")
+}
+
+func TestReceiverFields(t *testing.T) {
+	testdata := analysistest.TestData()
+	_ = Analyzer.Flags.Set("receivers", "true")
+	defer func() {
+		_ = Analyzer.Flags.Set("receivers", "false")
+	}()
+
+	analysistest.Run(t, testdata, Analyzer, "receiver")
+}
+
+func TestGlobalVars(t *testing.T) {
+	testdata := analysistest.TestData()
+	analysistest.Run(t, testdata, Analyzer, "global")
+}
+
+func TestIncludeTests(t *testing.T) {
+	testdata := analysistest.TestData()
+	_ = Analyzer.Flags.Set("tests", "true")
+	defer func() {
+		_ = Analyzer.Flags.Set("tests", "false")
+	}()
+
+	analysistest.Run(t, testdata, Analyzer, "tests")
+}
+
+func TestGuardFuncs(t *testing.T) {
+	testdata := analysistest.TestData()
+	_ = Analyzer.Flags.Set("guards", "guard/validate.Check:0:panic")
+	defer func() {
+		_ = Analyzer.Flags.Set("guards", "")
+	}()
+
+	analysistest.Run(t, testdata, Analyzer, "guard")
+}
+
+func TestSkipFiles(t *testing.T) {
+	testdata := analysistest.TestData()
+	_ = Analyzer.Flags.Set("exclude", "*_mock.go")
+	defer func() {
+		_ = Analyzer.Flags.Set("exclude", "")
+	}()
+
+	analysistest.Run(t, testdata, Analyzer, "generated")
+}
+
+func TestFuncBudget(t *testing.T) {
+	testdata := analysistest.TestData()
+	_ = Analyzer.Flags.Set("max-func-nodes", "40")
+	defer func() {
+		_ = Analyzer.Flags.Set("max-func-nodes", "0")
+	}()
+
+	analysistest.Run(t, testdata, Analyzer, "budget")
+}
+
+func TestExplain(t *testing.T) {
+	testdata := analysistest.TestData()
+	var output bytes.Buffer
+	explainOutput = &output
+	_ = Analyzer.Flags.Set("explain", "explain/explain.go:18")
+	defer func() {
+		_ = Analyzer.Flags.Set("explain", "")
+		explainOutput = os.Stderr
+	}()
+
+	analysistest.Run(t, testdata, Analyzer, "explain")
+
+	for _, want := range []string{
+		"explain explain/explain.go:18 in Dial",
+		"access path client.conn.Addr, root from outside: true",
+		"13:11           unchecked ptr    parameter: ignored, superseded by a later record",
+		"checked or terminated in every branch of the if statement: applies, treated as non-nil",
+		"client.conn:\n    no record applies, possibly nil since the root comes from outside",
+		"reported at 18:21: potential nil pointer reference",
+	} {
+		if !strings.Contains(output.String(), want) {
+			t.Errorf("explain output missing %q:\n%s", want, output.String())
+		}
+	}
+}
+
+func TestParseExplainTarget(t *testing.T) {
+	target, err := ParseExplainTarget("pkg/a.go:12:5")
+	if err != nil || *target != (ExplainTarget{File: "pkg/a.go", Line: 12, Column: 5}) {
+		t.Errorf("ParseExplainTarget = %v, %v", target, err)
+	}
+
+	for _, value := range []string{"a.go", "a.go:x", "a.go:0", "a.go:1:0", ":1", "a.go:1:2:3"} {
+		if _, err := ParseExplainTarget(value); err == nil {
+			t.Errorf("ParseExplainTarget(%q) want error", value)
+		}
+	}
+}
+
+func TestFuncContracts(t *testing.T) {
+	testdata := analysistest.TestData()
+	contractMap := make(map[string]string)
+	analyzer := &analysis.Analyzer{
+		Name:      "contracts",
+		Doc:       "collect nil contracts",
+		FactTypes: Analyzer.FactTypes,
+		Run: func(pass *analysis.Pass) (interface{}, error) {
+			contractList, err := GetFuncContracts(pass)
+			for _, contract := range contractList {
+				for _, param := range contract.Params {
+					contractMap[contract.Func+" "+param.Name] = param.Contract
+				}
+				for _, result := range contract.Results {
+					contractMap[fmt.Sprintf("%s result %d", contract.Func, result.Index)] = result.Contract
+				}
+			}
+			return nil, err
+		},
+	}
+
+	analysistest.Run(t, testdata, analyzer, "contracts")
+
+	want := map[string]string{
+		"Find store":               ContractChecked,
+		"Find result 0":            ContractMayBeNil,
+		"Name u":                   ContractDereferenced,
+		"Save store":               ContractChecked,
+		"Save u":                   ContractDereferenced,
+		"OrDefault u":              ContractChecked,
+		"OrDefault result 0":       ContractNeverNil,
+		"(*Store).Create result 0": ContractNeverNil,
+		"(*Store).Keep u":          ContractPassedThrough,
+	}
+	if !reflect.DeepEqual(contractMap, want) {
+		t.Errorf("contracts = %v, want %v", contractMap, want)
+	}
+}
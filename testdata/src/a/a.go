@@ -1,7 +1,11 @@
 package a
 
 import (
+	"context"
 	"fmt"
+	"log"
+	"net/http"
+	"os"
 )
 
 type Node struct {
@@ -16,6 +20,21 @@ type ChildNode struct {
 	GNode *GrandsonNode
 }
 
+type ChildNodeList struct {
+	List []*ChildNode
+}
+
+type DataConfig struct {
+	Name  string
+	Data  *DataInfo
+	Child ChildNode
+}
+
+type DataOptions struct {
+	OnError func(err error)
+	Labels  map[string]string
+}
+
 type ChildBrotherNode struct {
 	Score int32
 	GNode *GrandsonNode
@@ -352,6 +371,571 @@ func np12Example(d *DataInfo) {
 	// fmt.Println(d.A.B)
 }
 
+// A pointer obtained by a map lookup, unverified, directly referenced
+func np13Example(m map[string]*Node) {
+	n := m["a"]
+	fmt.Println(n.A) // want "potential nil pointer reference"
+
+	// The key may be missing, or its value may be nil.
+	// It can be written as follows, and will be more safe.
+	// n, ok := m["a"]
+	// if !ok {
+	//	 return
+	// }
+	// fmt.Println(n.A)
+}
+
+// A pointer obtained by a comma-ok map lookup, guarded by ok
+func np14Example(m map[string]*Node) {
+	n, ok := m["a"]
+	if !ok {
+		return
+	}
+	fmt.Println(n.A)
+}
+
+// A pointer obtained by a comma-ok map lookup, referenced in the branch where ok is false
+func np14NotOkExample(m map[string]*Node) {
+	n, ok := m["a"]
+	if !ok {
+		fmt.Println(n.A) // want "potential nil pointer reference"
+	}
+}
+
+// ok no longer belongs to the map lookup once it is reassigned
+func np14ReassignedOkExample(m, m2 map[string]*Node, other *Node) {
+	n, ok := m["a"]
+	_, ok = m2["a"]
+	if !ok {
+		return
+	}
+	fmt.Println(n.A) // want "potential nil pointer reference"
+
+	v, ok := m["b"]
+	ok = other != nil
+	if ok {
+		fmt.Println(v.A) // want "potential nil pointer reference"
+	}
+}
+
+// A pointer obtained by a type assertion with the ok result ignored, directly referenced
+func np15Example(x interface{}) {
+	n, _ := x.(*Node)
+	fmt.Println(n.A) // want "potential nil pointer reference"
+
+	// It can be written as follows, and will be more safe.
+	// n, ok := x.(*Node)
+	// if ok {
+	//	 fmt.Println(n.A)
+	// }
+}
+
+// A pointer obtained by a comma-ok type assertion, guarded by ok
+func np16Example(x interface{}) {
+	n, ok := x.(*Node)
+	if ok {
+		fmt.Println(n.A)
+	}
+}
+
+// A pointer received from a channel, unverified, directly referenced
+func np17Example(ch chan *Node) {
+	n := <-ch
+	fmt.Println(n.A) // want "potential nil pointer reference"
+
+	// The channel may be closed, or a nil pointer may be sent.
+	// It can be written as follows, and will be more safe.
+	// if n != nil {
+	//	 fmt.Println(n.A)
+	// }
+}
+
+// A pointer obtained by indexing a slice including pointers, unverified, directly referenced
+func np18Example(list []*Node) {
+	n := list[0]
+	fmt.Println(n.A) // want "potential nil pointer reference"
+
+	// It can be written as follows, and will be more safe.
+	// if n != nil {
+	//	 fmt.Println(n.A)
+	// }
+}
+
+// A pointer obtained with an error, referenced in the error branch
+func np19Example() error {
+	d, err := GetDataInfoWithErr()
+	if err != nil {
+		fmt.Println(d.A) // want "potential nil pointer reference"
+		return err
+	}
+
+	// d is not nil once err has been checked
+	fmt.Println(d.A)
+	return nil
+}
+
+// A pointer obtained with an error, referenced before the error is checked
+func np20Example() error {
+	d, err := GetDataInfoWithErr()
+	fmt.Println(d.A) // want "potential nil pointer reference"
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// A pointer obtained with an error, whose error is overwritten before it is checked
+func np20ReassignedErrExample() error {
+	d, err := GetDataInfoWithErr()
+	err = ValidateDataInfo()
+	if err != nil {
+		return err
+	}
+	fmt.Println(d.A) // want "potential nil pointer reference"
+
+	c, err := GetDataInfoWithErr()
+	_, err = GetDataInfoWithErr()
+	if err != nil {
+		return err
+	}
+	fmt.Println(c.A) // want "potential nil pointer reference"
+
+	return nil
+}
+
+// A pointer parameter referenced before it is later paired with an error
+func np20ParamPairedLaterExample(d *DataInfo) error {
+	fmt.Println(d.A) // want "potential nil pointer reference$"
+
+	d, err := RefreshDataInfo(d)
+	if err != nil {
+		return err
+	}
+	fmt.Println(d.A)
+
+	return nil
+}
+
+// The body of a response is closed in defer before the paired error is checked
+func np21Example(url string) error {
+	resp, err := http.Get(url)
+	defer resp.Body.Close()     // want "potential nil pointer reference before checking the paired error"
+	go fmt.Println(resp.Status) // want "potential nil pointer reference before checking the paired error"
+	if err != nil {
+		return err
+	}
+
+	// resp is nil when err is not nil, check err first:
+	// resp, err := http.Get(url)
+	// if err != nil {
+	//	 return err
+	// }
+	// defer resp.Body.Close()
+	return nil
+}
+
+// The body of a response is closed in defer after the paired error is checked
+func np22Example(url string) error {
+	resp, err := http.Get(url)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	go fmt.Println(resp.Status)
+	return nil
+}
+
+// A pointer declared in the if-init statement, referenced in the branch where it is checked
+func np23Example() {
+	if d := GetDataInfo(); d != nil {
+		fmt.Println(d.A)
+	}
+
+	if d := GetDataInfo(); d == nil {
+		fmt.Println(d.A) // want "potential nil pointer reference"
+	}
+}
+
+// A pointer referenced in the else branch of a nil check
+func np24Example(d *DataInfo) {
+	if d == nil {
+		fmt.Println("d is nil")
+	} else {
+		fmt.Println(d.A)
+	}
+
+	if d != nil {
+		fmt.Println(d.A)
+	} else {
+		fmt.Println(d.A) // want "potential nil pointer reference"
+	}
+
+	// d is only checked in the branches above
+	fmt.Println(d.A) // want "potential nil pointer reference"
+}
+
+// A pointer checked by a tagless switch
+func np25Example(d *DataInfo) {
+	switch {
+	case d == nil:
+		return
+	default:
+		fmt.Println(d.A)
+	}
+
+	// d is not nil once the nil case has returned
+	fmt.Println(d.A)
+}
+
+// A pointer referenced in the default case of a switch that does not exclude nil
+func np26Example(d *DataInfo) {
+	switch {
+	case d != nil:
+		fmt.Println(d.A)
+	default:
+		fmt.Println(d.A) // want "potential nil pointer reference"
+	}
+
+	switch d {
+	case nil:
+		fmt.Println(d.A) // want "potential nil pointer reference"
+	default:
+		fmt.Println(d.A)
+	}
+}
+
+// A pointer and its child are checked together with &&
+func np27Example(d *DataInfo) {
+	if d != nil && d.A != nil {
+		fmt.Println(d.A.B)
+	}
+
+	// d.A is referenced in the right operand, which is only evaluated when d is not nil
+	ok := d != nil && d.A != nil
+	fmt.Println(ok)
+}
+
+// A pointer and its child are checked together with || and an early return
+func np28Example(d *DataInfo) {
+	if d == nil || d.A == nil {
+		return
+	}
+
+	fmt.Println(d.A.B)
+}
+
+// A pointer checked with negation or with nil on the left
+func np29Example(d *DataInfo) {
+	if !(d == nil) {
+		fmt.Println(d.A)
+	}
+
+	if nil != d {
+		fmt.Println(d.A)
+	}
+}
+
+// A pointer checked with || does not guard the right operand or the branch
+func np30Example(d *DataInfo) {
+	if d != nil || d.A != nil { // want "potential nil pointer reference"
+		fmt.Println(d.A.B) // want "potential nil pointer reference" "potential nil pointer reference"
+	}
+
+	// d.A is referenced in the right operand, which is only evaluated when d is nil.
+	// It can be written as follows, and will be more safe.
+	// if d != nil && d.A != nil {
+	//	 fmt.Println(d.A.B)
+	// }
+}
+
+// A checked pointer is referenced again after it or its parent is reassigned
+func np31Example(d *DataInfo) {
+	if d == nil || d.A == nil {
+		return
+	}
+	fmt.Println(d.A.B)
+
+	d.A = nil
+	fmt.Println(d.A.B) // want "potential nil pointer reference"
+
+	d = GetDataInfo()
+	fmt.Println(d.A) // want "potential nil pointer reference"
+}
+
+// A pointer is assigned a new object, only in one branch
+func np32Example(d *DataInfo, isNew bool) {
+	if d == nil {
+		d = &DataInfo{}
+	}
+	fmt.Println(d.A)
+
+	d = nil
+	if isNew {
+		d = &DataInfo{}
+	}
+	fmt.Println(d.A) // want "potential nil pointer reference"
+}
+
+// A pointer checked through a local alias of it or of its child
+func np33Example(d *DataInfo) {
+	a := d
+	if a == nil {
+		return
+	}
+	fmt.Println(d.A)
+
+	n := d.A
+	if n == nil {
+		return
+	}
+	fmt.Println(d.A.B)
+}
+
+// Pointers copied and swapped in a multi-assignment
+func np34Example(x *DataInfo, y *DataInfo) {
+	if x == nil {
+		return
+	}
+
+	a, b := x, y
+	fmt.Println(a.A)
+	fmt.Println(b.A) // want "potential nil pointer reference"
+
+	a, b = b, a
+	fmt.Println(a.A) // want "potential nil pointer reference"
+	fmt.Println(b.A)
+}
+
+// Elements of a pointer slice, map or array, referenced by index without validation
+func np35Example(list []*DataInfo, dataMap map[string]*DataInfo, arr [2]*DataInfo, key string) {
+	fmt.Println(list[0].A)      // want "potential nil pointer reference"
+	fmt.Println(dataMap[key].A) // want "potential nil pointer reference"
+	fmt.Println(arr[1].A)       // want "potential nil pointer reference"
+
+	if list[0] != nil {
+		fmt.Println(list[0].A)
+		fmt.Println(list[1].A) // want "potential nil pointer reference"
+	}
+
+	d := dataMap[key]
+	if d == nil {
+		return
+	}
+	fmt.Println(dataMap[key].A)
+}
+
+// An element of a pointer slice field, referenced by index in a chain
+func np36Example(n *ChildNodeList, i int) {
+	if n == nil {
+		return
+	}
+	fmt.Println(n.List[i].GNode.Age) // want "potential nil pointer reference" "potential nil pointer reference"
+
+	if n.List[i] == nil || n.List[i].GNode == nil {
+		return
+	}
+	fmt.Println(n.List[i].GNode.Age)
+
+	i = 0
+	fmt.Println(n.List[i].Score) // want "potential nil pointer reference"
+
+	if n.List[i] == nil {
+		return
+	}
+	i++
+	fmt.Println(n.List[i].Score) // want "potential nil pointer reference"
+}
+
+// Function-typed parameters, fields and map values, called without validation
+func np37Example(callback func(), opts *DataOptions, handlers map[string]func() error, name string) {
+	callback() // want "potential nil function call"
+	if callback != nil {
+		callback()
+	}
+
+	if opts == nil {
+		return
+	}
+	opts.OnError(nil) // want "potential nil function call"
+	if opts.OnError != nil {
+		opts.OnError(nil)
+	}
+
+	err := handlers[name]() // want "potential nil function call"
+	if handler := handlers[name]; handler != nil {
+		err = handler()
+	}
+	fmt.Println(err)
+}
+
+// Writing into a map parameter, field or declaration that may not be initialized
+func np38Example(counts map[string]int, opts *DataOptions, key string) {
+	counts[key]++ // want "potential nil map write"
+
+	if opts == nil {
+		return
+	}
+	opts.Labels[key] = "v" // want "potential nil map write"
+	if opts.Labels == nil {
+		opts.Labels = make(map[string]string)
+	}
+	opts.Labels[key] = "v"
+
+	var cache map[string]*DataInfo
+	cache[key] = nil // want "potential nil map write"
+
+	cache = map[string]*DataInfo{}
+	cache[key] = nil
+}
+
+// Single-value type assertions not established by a comma-ok check or a type switch
+func np39Example(x interface{}) {
+	d := x.(*DataInfo) // want "unchecked type assertion"
+	fmt.Println(d)
+
+	if _, ok := x.(*DataInfo); ok {
+		fmt.Println(x.(*DataInfo).A)
+	}
+
+	node, ok := x.(*Node)
+	fmt.Println(node.A) // want "potential nil pointer reference"
+	if !ok {
+		return
+	}
+	fmt.Println(node.A)
+	fmt.Println(x.(*Node).B)
+}
+
+type ctxKey struct{}
+
+// The asserted pointer may be nil even when the function has no pointer parameters
+func np39CtxExample(ctx context.Context) {
+	_ = ctx.Value(ctxKey{}).(*DataInfo).A // want "unchecked type assertion" "potential nil pointer reference"
+}
+
+// A type switch establishes the dynamic type inside its case clauses
+func np40Example(x interface{}) {
+	switch x.(type) {
+	case *DataInfo:
+		fmt.Println(x.(*DataInfo).A)
+	case string:
+		fmt.Println(x.(string))
+	}
+
+	fmt.Println(x.(fmt.Stringer).String()) // want "unchecked type assertion"
+}
+
+// A possibly nil pointer returned or assigned as an interface is a non-nil interface
+func np41Example(isBad bool) error {
+	var dataErr *DataError
+	if isBad {
+		dataErr = &DataError{}
+	}
+
+	var err error = dataErr // want "potential nil pointer stored in a non-nil interface"
+	fmt.Println(err)
+
+	err = GetDataError() // want "potential nil pointer stored in a non-nil interface"
+	fmt.Println(err)
+
+	if dataErr == nil {
+		return nil
+	}
+	return dataErr
+}
+
+// A pointer parameter or field stored in an interface is not reported, its nilness is up to the caller;
+// a container element stored in an interface is reported
+func np42Example(dataErr *DataError, errs []*DataError, d *DataInfo) error {
+	var value interface{} = d
+	fmt.Println(value)
+
+	if len(errs) > 0 {
+		return errs[0] // want "potential nil pointer stored in a non-nil interface"
+	}
+	return dataErr
+}
+
+var errTable = [...]*DataError{{}, {}}
+
+// An element of a package-level lookup table stored in an interface is not reported, directly or through a local copy
+func np42TableExample(i int, errs []*DataError) error {
+	if i == 0 {
+		return errTable[i]
+	}
+
+	if i == 1 {
+		e := errTable[i]
+		return e
+	}
+
+	e := errs[i]
+	return e // want "potential nil pointer stored in a non-nil interface"
+}
+
+// Struct-valued parameter, its pointer fields are directly referenced without validation
+func np43Example(conf DataConfig) {
+	fmt.Println(conf.Data.A)          // want "potential nil pointer reference"
+	fmt.Println(conf.Child.GNode.Age) // want "potential nil pointer reference"
+	fmt.Println(conf.Name)
+
+	if conf.Data != nil {
+		fmt.Println(conf.Data.A)
+	}
+
+	gNode := conf.Child.GNode
+	if gNode == nil {
+		return
+	}
+	fmt.Println(conf.Child.GNode.Age)
+}
+
+// A pointer parameter checked inside a deferred or goroutine closure
+func np22ClosureExample(d *Node) {
+	go func() {
+		if d == nil {
+			return
+		}
+		fmt.Println(d.A)
+	}()
+
+	defer func() {
+		if d != nil {
+			fmt.Println(d.A)
+		}
+	}()
+
+	defer func() {
+		fmt.Println(d.A) // want "potential nil pointer reference"
+	}()
+}
+
+type fatalLogger struct{}
+
+func (fatalLogger) Fatal(v ...interface{}) {}
+
+// os.Exit and log.Fatal end the function, so np44Example is also inferred as a guard of d;
+// Fatal on a local variable named log does not
+func np44Example(d, e, g *DataInfo) { // want np44Example:"guard\\(0, panic\\)"
+	if d == nil {
+		os.Exit(1)
+	}
+	fmt.Println(d.A)
+
+	if e == nil {
+		log.Fatal("e is nil")
+	}
+	fmt.Println(e.A)
+
+	log := fatalLogger{}
+	if g == nil {
+		log.Fatal("g is nil")
+	}
+	fmt.Println(g.A) // want "potential nil pointer reference"
+}
+
 func GetDataInfo() *DataInfo {
 	return nil
 }
@@ -361,3 +945,27 @@ func GetDataInfoList() []*DataInfo {
 		nil, nil, nil,
 	}
 }
+
+func GetDataInfoWithErr() (*DataInfo, error) {
+	return nil, nil
+}
+
+func RefreshDataInfo(d *DataInfo) (*DataInfo, error) {
+	return d, nil
+}
+
+func ValidateDataInfo() error {
+	return nil
+}
+
+type DataError struct {
+	Message string
+}
+
+func (e *DataError) Error() string {
+	return e.Message
+}
+
+func GetDataError() *DataError {
+	return nil
+}
@@ -0,0 +1,99 @@
+package a
+
+import (
+	"fmt"
+)
+
+type NodePtr interface {
+	*Node
+	GetScore() int
+}
+
+func (n *Node) GetScore() int {
+	return n.A
+}
+
+type NodeList[T any] struct {
+	head *T
+}
+
+func (l *NodeList[T]) Front() *T {
+	return l.head
+}
+
+type NodeBox[T any] struct {
+	Val T
+}
+
+func FirstOf[T any](list []*T) *T {
+	if len(list) == 0 {
+		return nil
+	}
+	return list[0]
+}
+
+func PickOf[T any](list []T) T {
+	var zero T
+	return zero
+}
+
+func LoadOf[P NodePtr]() P {
+	return nil
+}
+
+// A pointer obtained by calling an instantiated generic function, unverified, directly referenced
+func gnp1Example(list []*Node) {
+	first := FirstOf[Node](list)
+	fmt.Println(first.A) // want "potential nil pointer reference"
+
+	picked := PickOf(list)
+	fmt.Println(picked.B) // want "potential nil pointer reference"
+
+	loaded := LoadOf[*Node]()
+	fmt.Println(loaded.C) // want "potential nil pointer reference"
+
+	picked = PickOf[*Node](list)
+	if picked == nil {
+		return
+	}
+	fmt.Println(picked.B)
+}
+
+// Function parameter is a type parameter whose core type is a pointer
+func gnp2Example[P NodePtr](p P, q P) {
+	fmt.Println(p.GetScore()) // want "potential nil pointer reference"
+
+	if q == nil {
+		return
+	}
+	fmt.Println(q.GetScore())
+}
+
+// A pointer returned by a method of a generic receiver, or held in a field of an instantiated generic type
+func gnp3Example(l *NodeList[Node], b *NodeBox[*Node]) {
+	if l == nil || b == nil {
+		return
+	}
+
+	fmt.Println(l.Front().A) // want "potential nil pointer reference"
+	fmt.Println(b.Val.A)     // want "potential nil pointer reference"
+
+	if b.Val != nil {
+		fmt.Println(b.Val.A)
+	}
+}
+
+// A pointer returned by an instantiated generic function or a plain function, referenced directly in a selector
+func gnp4Example(list []*Node) {
+	fmt.Println(FirstOf[Node](list).A) // want "potential nil pointer reference"
+	fmt.Println(FirstOf(list).B)       // want "potential nil pointer reference"
+	fmt.Println(GetDataInfo().A)       // want "potential nil pointer reference"
+}
+
+var nodes []*Node
+
+// A function without pointer parameters, the call results are still checked
+func gnp5Example() {
+	fmt.Println(FirstOf(nodes).A) // want "potential nil pointer reference"
+	fmt.Println(GetDataInfo().A)  // want "potential nil pointer reference"
+}
@@ -0,0 +1,35 @@
+package a
+
+import (
+	"fmt"
+	"unsafe"
+)
+
+type Handler func(n *Node) *Node
+
+// Unusual code that must not crash the analyzer: blank identifiers, builtins, conversions and calls through function-typed expressions
+func unusualExample(buf []byte, handlers map[string]Handler, getHandler func() Handler, n *Node) {
+	_ = len(buf)
+	_, _ = fmt.Println(string(buf))
+
+	var _ *Node = (*Node)(unsafe.Pointer(n))
+	for range make([]int, cap(buf)) {
+	}
+
+	for _, b := range []byte(string(buf)) {
+		fmt.Println(b)
+	}
+
+	if h := handlers["a"]; h != nil {
+		fmt.Println(h(n))
+	}
+
+	if h := getHandler(); h != nil { // want "potential nil function call"
+		fmt.Println(h(nil))
+	}
+
+	m := new(Node)
+	fmt.Println(m.A, complex(1, 2), append(buf, 'a'))
+	copy(buf, buf)
+	println(real(complex(1, 2)))
+}
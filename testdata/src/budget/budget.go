@@ -0,0 +1,25 @@
+package budget
+
+import "fmt"
+
+type Node struct {
+	A    int
+	Next *Node
+}
+
+// A function within -max-func-nodes is checked as usual
+func smallExample(n *Node) {
+	fmt.Println(n.A) // want "potential nil pointer reference"
+}
+
+// A function exceeding -max-func-nodes is skipped with a note
+func bigExample(n *Node) { // want `npecheck skipped bigExample: \d+ AST nodes exceeds -max-func-nodes=40`
+	fmt.Println(n.A)
+	fmt.Println(n.Next.A)
+	if n.Next != nil {
+		fmt.Println(n.Next.Next.A)
+	}
+	for i := 0; i < n.A; i++ {
+		fmt.Println(i, n.Next.A)
+	}
+}
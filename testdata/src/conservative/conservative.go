@@ -0,0 +1,56 @@
+package conservative
+
+import (
+	"fmt"
+)
+
+type Node struct {
+	Score int
+	Child *Node
+}
+
+func (n *Node) Reset() {
+	n.Child = nil
+}
+
+func (n Node) Print() {
+	fmt.Println(n.Score)
+}
+
+func reset(n *Node) {
+	if n != nil {
+		n.Child = nil
+	}
+}
+
+// The checked field of a struct is referenced after a method that may reset it
+func np1Example(n *Node) {
+	if n == nil || n.Child == nil {
+		return
+	}
+	fmt.Println(n.Child.Score)
+
+	n.Reset()
+	fmt.Println(n.Child.Score) // want "potential nil pointer reference"
+	fmt.Println(n.Score)
+}
+
+// The checked field of a struct is referenced after a function that may reset it
+func np2Example(n *Node) {
+	if n == nil || n.Child == nil {
+		return
+	}
+
+	reset(n)
+	fmt.Println(n.Child.Score) // want "potential nil pointer reference"
+}
+
+// The checked field of a struct is referenced after a method with a value receiver
+func np3Example(n *Node) {
+	if n == nil || n.Child == nil {
+		return
+	}
+
+	n.Print()
+	fmt.Println(n.Child.Score)
+}
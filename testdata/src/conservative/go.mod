@@ -0,0 +1,3 @@
+module conservative
+
+go 1.19
@@ -0,0 +1,55 @@
+package contracts
+
+type User struct {
+	Name string
+}
+
+type Store struct {
+	users map[string]*User
+}
+
+func Find(store *Store, name string) *User {
+	if store == nil {
+		return nil
+	}
+
+	return store.users[name]
+}
+
+func Name(u *User) string {
+	return u.Name
+}
+
+func Save(store *Store, u *User) {
+	if store != nil {
+		store.users[u.Name] = u
+	}
+}
+
+func OrDefault(u *User) *User {
+	if u == nil {
+		u = &User{}
+	}
+
+	return u
+}
+
+func (s *Store) Create(name string) (*User, error) {
+	u := new(User)
+	u.Name = name
+	return u, nil
+}
+
+func (s *Store) Keep(u *User) {
+	s.users[""] = u
+}
+
+func lookup(store *Store) *User {
+	return store.users[""]
+}
+
+type index struct{}
+
+func (i *index) Get(u *User) *User {
+	return u
+}
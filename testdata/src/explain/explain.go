@@ -0,0 +1,19 @@
+package explain
+
+import "fmt"
+
+type Conn struct {
+	Addr string
+}
+
+type Client struct {
+	conn *Conn
+}
+
+func Dial(client *Client) {
+	if client == nil {
+		return
+	}
+
+	fmt.Println(client.conn.Addr) // want "potential nil pointer reference"
+}
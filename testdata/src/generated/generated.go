@@ -0,0 +1,12 @@
+package generated
+
+import "fmt"
+
+// A pointer field of a generated type, unverified, directly referenced outside of the generated file
+func gnp1Example(u *User) {
+	if u == nil {
+		return
+	}
+
+	fmt.Println(u.Profile.Email) // want "potential nil pointer reference"
+}
@@ -0,0 +1,11 @@
+package generated
+
+type MockStore struct {
+	user *User
+}
+
+func (m *MockStore) Load(u *User) {
+	m.user = u
+	name := u.Profile.Email
+	_ = name
+}
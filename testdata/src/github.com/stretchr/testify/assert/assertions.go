@@ -0,0 +1,25 @@
+package assert
+
+type TestingT interface {
+	Errorf(format string, args ...interface{})
+}
+
+type Assertions struct {
+	t TestingT
+}
+
+func New(t TestingT) *Assertions {
+	return &Assertions{t: t}
+}
+
+func NotNil(t TestingT, object interface{}, msgAndArgs ...interface{}) bool {
+	return object != nil
+}
+
+func NoError(t TestingT, err error, msgAndArgs ...interface{}) bool {
+	return err == nil
+}
+
+func (a *Assertions) NotNil(object interface{}, msgAndArgs ...interface{}) bool {
+	return NotNil(a.t, object, msgAndArgs...)
+}
@@ -0,0 +1,27 @@
+package require
+
+import "github.com/stretchr/testify/assert"
+
+type Assertions struct {
+	t assert.TestingT
+}
+
+func New(t assert.TestingT) *Assertions {
+	return &Assertions{t: t}
+}
+
+func NotNil(t assert.TestingT, object interface{}, msgAndArgs ...interface{}) {
+	if !assert.NotNil(t, object, msgAndArgs...) {
+		panic("FailNow")
+	}
+}
+
+func NoError(t assert.TestingT, err error, msgAndArgs ...interface{}) {
+	if !assert.NoError(t, err, msgAndArgs...) {
+		panic("FailNow")
+	}
+}
+
+func (a *Assertions) NotNil(object interface{}, msgAndArgs ...interface{}) {
+	NotNil(a.t, object, msgAndArgs...)
+}
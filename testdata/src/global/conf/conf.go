@@ -0,0 +1,20 @@
+package conf
+
+type Config struct {
+	Name string
+	Port int
+}
+
+// Default 在 init() 中初始化, 非 nil
+var Default *Config
+
+// Current 在 Load() 中赋值, 可能为 nil
+var Current *Config
+
+func init() {
+	Default = &Config{Name: "default"}
+}
+
+func Load(name string) {
+	Current = &Config{Name: name}
+}
@@ -0,0 +1,66 @@
+package global
+
+import (
+	"fmt"
+	"os"
+
+	"global/conf"
+)
+
+type Store struct {
+	Name string
+}
+
+var (
+	// 包级别初始化, 非 nil
+	defaultStore = &Store{Name: "default"}
+
+	// 在 init() 中初始化, 非 nil
+	initStore *Store
+
+	// 在 init() 中有条件地初始化, 可能为 nil
+	envStore *Store // want envStore:"nilable"
+
+	// 在 Open() 中赋值, 可能为 nil
+	store *Store // want store:"nilable"
+
+	// 在包级别初始化为 nil
+	nilStore *Store = nil // want nilStore:"nilable"
+
+	storeName = store.Name // want "potential nil pointer reference"
+)
+
+func init() {
+	initStore = &Store{Name: "init"}
+	if os.Getenv("STORE") != "" {
+		envStore = &Store{Name: "env"}
+	}
+}
+
+func Open(name string) {
+	store = &Store{Name: name}
+}
+
+// A global pointer assigned outside of init(), unverified, directly referenced
+func gnp1Example() {
+	fmt.Println(defaultStore.Name)
+	fmt.Println(initStore.Name)
+	fmt.Println(envStore.Name) // want "potential nil pointer reference"
+	fmt.Println(store.Name)    // want "potential nil pointer reference"
+	fmt.Println(nilStore.Name) // want "potential nil pointer reference"
+
+	if store != nil {
+		fmt.Println(store.Name)
+	}
+}
+
+// A global pointer of another package marked as nilable, unverified, directly referenced
+func gnp2Example() {
+	fmt.Println(conf.Default.Name)
+	fmt.Println(conf.Current.Name) // want "potential nil pointer reference"
+
+	if conf.Current == nil {
+		return
+	}
+	fmt.Println(conf.Current.Port)
+}
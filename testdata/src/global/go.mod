@@ -0,0 +1,3 @@
+module global
+
+go 1.19
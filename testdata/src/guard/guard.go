@@ -0,0 +1,67 @@
+package guard
+
+import (
+	"fmt"
+
+	"guard/validate"
+)
+
+type Pool struct {
+	Size int
+}
+
+type Service struct {
+	Pool *Pool
+}
+
+func isReady(s *Service) bool { // want isReady:"guard\\(0, bool\\)"
+	return s != nil && s.Pool != nil
+}
+
+func hasPool(s *Service) bool { // want hasPool:"guard\\(0, bool\\)"
+	return !(s == nil)
+}
+
+// A pointer verified by an inferred boolean guard function of the same package
+func gnp1Example(s *Service) {
+	if !hasPool(s) {
+		return
+	}
+	fmt.Println(s.Pool)
+
+	if !isReady(s) {
+		return
+	}
+	fmt.Println(s.Pool.Size) // want "potential nil pointer reference"
+}
+
+// A pointer verified by an inferred guard function of another package
+func gnp2Example(c *validate.Config, configs map[string]*validate.Config) {
+	if validate.Present(c) {
+		fmt.Println(c.Name)
+	}
+	fmt.Println(c.Name) // want "potential nil pointer reference"
+
+	validate.NotNil("configs", configs)
+	configs["a"] = c
+}
+
+// A function comparing an interface parameter with nil is not a guard, a typed nil pointer in an interface is not nil
+func gnp2InterfaceExample(s *Service) {
+	var p *Pool
+	if validate.PresentValue(p) {
+		fmt.Println(p.Size) // want "potential nil pointer reference"
+	}
+
+	if validate.PresentValue(s) {
+		fmt.Println(s.Pool) // want "potential nil pointer reference"
+	}
+}
+
+// A pointer verified by a guard function configured by -guards
+func gnp3Example(s *Service, p *Pool) {
+	validate.Check(s)
+	fmt.Println(s.Pool)
+
+	validate.Check(p.Size) // want "potential nil pointer reference"
+}
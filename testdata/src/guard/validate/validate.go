@@ -0,0 +1,38 @@
+package validate
+
+import "fmt"
+
+type Config struct {
+	Name string
+}
+
+// Present 返回 true 时 c 非 nil
+func Present(c *Config) bool { // want Present:"guard\\(0, bool\\)"
+	return c != nil
+}
+
+// NotNil m 为 nil 时 panic
+func NotNil(name string, m map[string]*Config) { // want NotNil:"guard\\(1, panic\\)"
+	if m == nil {
+		panic(fmt.Sprintf("%s is nil", name))
+	}
+}
+
+// PresentValue 不是校验函数, 装箱到接口中的 nil 指针不等于 nil
+func PresentValue(v interface{}) bool {
+	return v != nil
+}
+
+// Check 无法推断, 通过 -guards 参数配置
+func Check(v interface{}) {
+	if err := check(v); err != nil {
+		panic(err)
+	}
+}
+
+func check(v interface{}) error {
+	if v == nil {
+		return fmt.Errorf("nil value")
+	}
+	return nil
+}
@@ -0,0 +1,3 @@
+module iface
+
+go 1.19
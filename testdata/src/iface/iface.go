@@ -0,0 +1,60 @@
+package iface
+
+import (
+	"context"
+	"fmt"
+	"io"
+)
+
+type Logger interface {
+	Info(msg string)
+}
+
+type Store interface {
+	Get(key string) string
+}
+
+type Service struct {
+	Log   Logger
+	Store Store
+	Out   io.Writer
+}
+
+type nopLogger struct{}
+
+func (nopLogger) Info(msg string) {}
+
+// Interface-typed parameter, method called without validation
+func iface1Example(ctx context.Context, log Logger) {
+	fmt.Println(ctx.Err()) // context.Context is trusted
+	log.Info("start")      // want "potential nil pointer reference"
+
+	if log != nil {
+		log.Info("done")
+	}
+}
+
+// Interface-typed fields injected via a struct
+func iface2Example(svc *Service) {
+	if svc == nil {
+		return
+	}
+
+	svc.Log.Info("start")           // want "potential nil pointer reference"
+	fmt.Println(svc.Store.Get("k")) // want "potential nil pointer reference"
+	fmt.Fprintln(svc.Out, "x")
+	_, _ = svc.Out.Write(nil) // io.Writer is trusted
+
+	if svc.Store == nil {
+		return
+	}
+	fmt.Println(svc.Store.Get("k"))
+}
+
+// Interface-typed parameter replaced with a default implementation
+func iface3Example(log Logger) {
+	if log == nil {
+		log = nopLogger{}
+	}
+	log.Info("start")
+}
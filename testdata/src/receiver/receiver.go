@@ -0,0 +1,79 @@
+package receiver
+
+import (
+	"fmt"
+)
+
+type Cache struct {
+	Size int
+}
+
+type Repo struct {
+	cache *Cache
+}
+
+type Service struct {
+	repo  *Repo
+	audit *Repo
+	name  string
+}
+
+func NewService(repo *Repo) *Service {
+	s := &Service{repo: repo}
+	s.name = "service"
+	return s
+}
+
+func NewAuditService(repo *Repo, audit *Repo) *Service {
+	return &Service{repo: repo, audit: audit}
+}
+
+// The receiver and its pointer fields are referenced without validation
+func (s *Service) Do() {
+	fmt.Println(s.repo.cache.Size) // want "potential nil pointer reference" "potential nil pointer reference"
+	fmt.Println(s.audit.cache)     // want "potential nil pointer reference" "potential nil pointer reference"
+}
+
+// The receiver is checked, repo is always set by the constructors, audit is not
+func (s *Service) Audit() {
+	if s == nil {
+		return
+	}
+
+	fmt.Println(s.repo.cache)  // repo is always set by the constructors
+	fmt.Println(s.audit.cache) // want "potential nil pointer reference"
+
+	if s.audit != nil {
+		fmt.Println(s.audit.cache)
+	}
+}
+
+// A value receiver, its pointer fields are referenced without validation
+func (s Service) Name() {
+	fmt.Println(s.repo.cache)
+	fmt.Println(s.audit.cache) // want "potential nil pointer reference"
+}
+
+type Store struct {
+	repo  *Repo
+	cache *Cache
+}
+
+func NewStore(repo *Repo, withCache bool) *Store {
+	s := &Store{}
+	s.repo = repo
+	if withCache {
+		s.cache = &Cache{}
+	}
+	return s
+}
+
+// cache is only set conditionally by the constructor
+func (s *Store) Size() {
+	if s == nil {
+		return
+	}
+
+	fmt.Println(s.repo.cache) // repo is always set by the constructor
+	fmt.Println(s.cache.Size) // want "potential nil pointer reference"
+}
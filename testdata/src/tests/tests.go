@@ -0,0 +1,39 @@
+package tests
+
+import "errors"
+
+type User struct {
+	Name    string
+	Profile *Profile
+}
+
+type Profile struct {
+	Email string
+}
+
+func FindUser(id int) (*User, error) {
+	if id == 0 {
+		return nil, errors.New("not found")
+	}
+	return &User{}, nil
+}
+
+func GetProfile(u *User) *Profile {
+	if u == nil {
+		return nil
+	}
+	return u.Profile
+}
+
+type Runner struct{}
+
+func (Runner) Skip(n int) {}
+
+// A Skip method on a type other than testing.T does not stop the function
+func Process(r Runner, u *User) string {
+	if u == nil {
+		r.Skip(1)
+	}
+	name := u.Name // want "potential nil pointer reference"
+	return name
+}
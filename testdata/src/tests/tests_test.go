@@ -0,0 +1,65 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// A pointer verified by a require assertion, referenced afterwards
+func TestRequire(t *testing.T) {
+	u, err := FindUser(1)
+	require.NoError(t, err)
+	require.NotNil(t, u.Profile)
+	t.Log(u.Name, u.Profile.Email)
+
+	p := GetProfile(u)
+	r := require.New(t)
+	r.NotNil(p)
+	t.Log(p.Email)
+}
+
+// A pointer verified by an assert assertion followed by return
+func TestAssert(t *testing.T) {
+	u, _ := FindUser(1)
+	if !assert.NotNil(t, u) {
+		return
+	}
+	t.Log(u.Name)
+
+	p := GetProfile(u)
+	assert.NotNil(t, p)
+	t.Log(p.Email) // want "potential nil pointer reference"
+}
+
+// A pointer verified by t.Fatal or t.FailNow
+func TestFatal(t *testing.T) {
+	u, _ := FindUser(1)
+	if u == nil {
+		t.Fatalf("user not found")
+	}
+	t.Log(u.Name)
+
+	p := GetProfile(u)
+	if p == nil {
+		t.FailNow()
+	}
+	t.Log(p.Email)
+}
+
+// A pointer verified by testing.TB.SkipNow
+func TestSkipTB(t *testing.T) {
+	var tb testing.TB = t
+	u, _ := FindUser(2)
+	if u == nil {
+		tb.SkipNow()
+	}
+	tb.Log(u.Name)
+}
+
+// A pointer unverified in a benchmark, directly referenced
+func BenchmarkFind(b *testing.B) {
+	u, _ := FindUser(b.N)
+	b.Log(u.Name) // want "potential nil pointer reference"
+}